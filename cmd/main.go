@@ -8,17 +8,24 @@ import (
 
 	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	showVersion bool
 	showDevices bool
-	metricsPort int
+	printRules  string
+	output      string
+	smartctl    bool
+	options     = devmon.NewOptions()
 
 	rootCmd = &cobra.Command{
 		Use:   "hpessa-exporter",
 		Short: "Storage devices monitor",
-		Run:   func(cmd *cobra.Command, args []string) { start() },
+		Run: func(cmd *cobra.Command, args []string) {
+			parseCollectorsFlags(cmd.Flags())
+			start()
+		},
 	}
 )
 
@@ -27,8 +34,66 @@ func init() {
 		"version", "v", false, "show version and exit")
 	rootCmd.Flags().BoolVarP(&showDevices,
 		"show", "s", false, "probe-print devices and exit")
-	rootCmd.Flags().IntVarP(&metricsPort,
+	rootCmd.Flags().StringVarP(&output,
+		"output", "o", "text", "probe-print output format (text or json)")
+	rootCmd.Flags().StringVar(&printRules,
+		"print-rules", "", "print alerting rules (as prometheusrule or rules file) and exit")
+	rootCmd.Flags().IntVarP(&options.MetricsPort,
 		"port", "p", devmon.DefaultMetricsPort, "metrics port")
+	rootCmd.Flags().StringVar(&options.HostRoot,
+		"host-root", devmon.DefaultHostRoot, "prefix of host's /sys, /dev, /proc and /run/udev mount points")
+	rootCmd.Flags().StringSliceVar(&options.UdevProperties,
+		"udev-properties", devmon.DefaultUdevProperties,
+		"udev properties to expose as block-device info labels")
+	rootCmd.Flags().BoolVar(&smartctl,
+		"smartctl", false, "export SMART data of physical drives via smartctl")
+	_ = rootCmd.Flags().MarkDeprecated("smartctl", "use --collector.smartctl instead")
+	rootCmd.Flags().StringVar(&options.RedfishSecretDir,
+		"redfish-secret-dir", "", "BMC (iLO) Redfish endpoint and credentials directory")
+	rootCmd.Flags().DurationVar(&options.ScrapeInterval,
+		"scrape-interval", devmon.DefaultScrapeInterval,
+		"expected interval between metrics scrapes (for liveness and readiness)")
+	rootCmd.Flags().BoolVar(&options.NodenameLabel,
+		"nodename-label", false, "add nodename label to all metrics")
+	rootCmd.Flags().StringVar(&options.Kubernetes,
+		"kubernetes", devmon.KubernetesAuto,
+		"kubernetes integration mode (auto, on or off)")
+	rootCmd.Flags().BoolVar(&options.Events,
+		"events", true,
+		"emit node events upon RAID status transitions between --node-interval ticks")
+	rootCmd.Flags().BoolVar(&options.NodeLabels,
+		"node-labels", false, "publish RAID health as node labels and annotations")
+	rootCmd.Flags().BoolVar(&options.NodeCondition,
+		"node-condition", false, "maintain StorageHealthy node condition")
+	rootCmd.Flags().BoolVar(&options.Inventory,
+		"inventory", false, "maintain StorageNodeInventory custom resource of node")
+	rootCmd.Flags().DurationVar(&options.NodeInterval,
+		"node-interval", devmon.DefaultNodeInterval,
+		"interval between node updates and events (shorter status flaps are not reported)")
+	rootCmd.Flags().StringVar(&options.WebConfigFile,
+		"web.config.file", "", "path to web configuration file (TLS and basic auth)")
+	rootCmd.Flags().BoolVar(&options.TokenReview,
+		"web.token-review", false,
+		"authorize metrics requests by bearer token via Kubernetes API")
+	for _, name := range devmon.CollectorNames() {
+		rootCmd.Flags().Bool("collector."+name, devmon.DefaultCollectorEnabled(name),
+			fmt.Sprintf("enable the %s collector", name))
+		rootCmd.Flags().Bool("no-collector."+name, false,
+			fmt.Sprintf("disable the %s collector", name))
+	}
+}
+
+// parseCollectorsFlags resolves the enabled collectors, where
+// --no-collector.<name> takes precedence over --collector.<name>
+func parseCollectorsFlags(flags *pflag.FlagSet) {
+	for _, name := range devmon.CollectorNames() {
+		enabled, _ := flags.GetBool("collector." + name)
+		disabled, _ := flags.GetBool("no-collector." + name)
+		if name == "smartctl" {
+			enabled = enabled || smartctl
+		}
+		options.Collectors[name] = enabled && !disabled
+	}
 }
 
 func main() {
@@ -44,13 +109,31 @@ func start() {
 			runtime.Version(), runtime.GOOS, runtime.GOARCH)
 		os.Exit(0)
 	}
+	if printRules != "" {
+		rules, err := devmon.PrintAlertRules(printRules)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Print(rules)
+		os.Exit(0)
+	}
 	if showDevices {
-		if err := devmon.ProbePrintDevices(); err != nil {
+		probePrint := devmon.ProbePrintDevices
+		switch output {
+		case "text":
+		case "json":
+			probePrint = devmon.ProbePrintInventory
+		default:
+			fmt.Fprintf(os.Stderr, "unknown output format: %s\n", output)
+			os.Exit(1)
+		}
+		if err := probePrint(options); err != nil {
 			os.Exit(1)
 		}
 		os.Exit(0)
 	}
-	if err := devmon.RunDevicesExporter(metricsPort); err != nil {
+	if err := devmon.RunDevicesExporter(options); err != nil {
 		os.Exit(1)
 	}
 }
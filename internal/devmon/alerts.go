@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AlertRulesName is the name of the PrometheusRule and of its rules group
+	AlertRulesName = "hpessa-exporter-rules"
+	// AlertRulesNamespace is the namespace of the PrometheusRule
+	AlertRulesNamespace = "openshift-storage-hpessa"
+
+	// DriveTemperatureThreshold is the temperature (in Celsius) above which
+	// physical drives are alerted on
+	DriveTemperatureThreshold = 55
+	// SSDUsageRemainingThreshold is the remaining endurance percentage below
+	// which SSDs are alerted on
+	SSDUsageRemainingThreshold = 10
+)
+
+// AlertRule is a Prometheus alerting rule
+type AlertRule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AlertRuleGroup is a named group of alerting rules
+type AlertRuleGroup struct {
+	Name  string      `json:"name"`
+	Rules []AlertRule `json:"rules"`
+}
+
+// AlertRuleGroups is the content of Prometheus rules file, as well as the
+// spec of PrometheusRule custom resource.
+type AlertRuleGroups struct {
+	Groups []AlertRuleGroup `json:"groups"`
+}
+
+type prometheusRuleMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+type prometheusRule struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   prometheusRuleMeta `json:"metadata"`
+	Spec       AlertRuleGroups    `json:"spec"`
+}
+
+// metricNameIndex maps the names of the exporter's metrics, as described by
+// its collectors, without their namespace prefix, to their full names.
+type metricNameIndex struct {
+	names   map[string]string
+	missing []string
+}
+
+func newMetricNameIndex() *metricNameIndex {
+	idx := &metricNameIndex{names: map[string]string{}}
+	for _, name := range MetricNames() {
+		idx.names[strings.TrimPrefix(name, collectorsNamespace+"_")] = name
+	}
+	return idx
+}
+
+// name returns the full name of a metric which is described by one of the
+// collectors; other names are recorded as missing (see err).
+func (idx *metricNameIndex) name(name string) string {
+	fqName, ok := idx.names[name]
+	if !ok {
+		idx.missing = append(idx.missing, name)
+		return collectorsNamespace + "_" + name
+	}
+	return fqName
+}
+
+// err returns non-nil error if any of the names is not described by the
+// collectors, so that alerting rules do not refer to metrics which the
+// exporter does not export.
+func (idx *metricNameIndex) err() error {
+	if len(idx.missing) > 0 {
+		return fmt.Errorf("no collector describes metrics: %s",
+			strings.Join(idx.missing, ", "))
+	}
+	return nil
+}
+
+// alertedCollectors returns the names of the collectors whose scrape failure
+// is alerted on: those enabled by default, as opposed to opt-in ones (e.g.
+// smartctl) which may fail on some of the hosts by design.
+func alertedCollectors() []string {
+	ret := []string{}
+	for _, name := range CollectorNames() {
+		if defaultCollectors[name] {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+func newAlertRule(alert, expr, dur, severity, summary, desc string) AlertRule {
+	return AlertRule{
+		Alert:  alert,
+		Expr:   expr,
+		For:    dur,
+		Labels: map[string]string{"severity": severity},
+		Annotations: map[string]string{
+			"summary":     summary,
+			"description": desc,
+		},
+	}
+}
+
+// AlertRules returns the alerting rules on the exporter's metrics: RAID
+// controllers, caches and batteries failures, logical drives degradation or
+// failure, physical drives failure, predictive failure, wear-out and
+// temperature, and the exporter's own scrape failures.
+func AlertRules() (*AlertRuleGroups, error) {
+	metrics := newMetricNameIndex()
+	ctrlStatus := metrics.name("raid_controller_status")
+	cacheStatus := metrics.name("raid_controller_cache_status")
+	batteryStatus := metrics.name("raid_controller_battery_status")
+	ldStatus := metrics.name("raid_logical_device_status")
+	pdStatus := metrics.name("raid_physical_device_status")
+	pdTemp := metrics.name("raid_physical_device_temp_curr")
+	pdUsage := metrics.name("raid_physical_device_usage_remaining")
+	smartPassed := metrics.name("raid_physical_device_smart_passed")
+	scrapeSuccess := metrics.name("scrape_collector_success")
+	exporterInfo := metrics.name("exporter_info")
+	failed := `"Failed|Offline"`
+	onNode := " on {{ $labels.instance }}"
+
+	rules := []AlertRule{
+		newAlertRule("RaidControllerFailed",
+			ctrlStatus+" > 0", "5m", "critical",
+			"RAID controller failed",
+			"RAID controller {{ $labels.controller }} ({{ $labels.vendor }})"+
+				onNode+" reports status {{ $labels.status }}."),
+
+		newAlertRule("RaidControllerCacheFailed",
+			cacheStatus+" > 0", "15m", "warning",
+			"RAID controller cache failed",
+			"Cache of RAID controller {{ $labels.controller }}"+
+				onNode+" reports status {{ $labels.status }}."),
+
+		newAlertRule("RaidCacheBatteryFailed",
+			batteryStatus+" > 0", "15m", "warning",
+			"RAID controller cache battery failed",
+			"Cache battery (or capacitor) of RAID controller {{ $labels.controller }}"+
+				onNode+" reports status {{ $labels.status }}."),
+
+		newAlertRule("RaidLogicalDriveFailed",
+			fmt.Sprintf("%s{status=~%s} > 0", ldStatus, failed), "5m", "critical",
+			"RAID logical drive failed",
+			"Logical drive {{ $labels.diskname }} of array {{ $labels.arrayname }}"+
+				onNode+" reports status {{ $labels.status }}."),
+
+		newAlertRule("RaidLogicalDriveDegraded",
+			fmt.Sprintf("%s{status!~%s} > 0", ldStatus, failed), "15m", "warning",
+			"RAID logical drive degraded",
+			"Logical drive {{ $labels.diskname }} of array {{ $labels.arrayname }}"+
+				onNode+" reports status {{ $labels.status }}."),
+
+		newAlertRule("RaidPhysicalDriveFailed",
+			fmt.Sprintf("%s{status=~%s} > 0", pdStatus, failed), "5m", "critical",
+			"RAID physical drive failed",
+			"Physical drive {{ $labels.id }} (box {{ $labels.box }}, bay {{ $labels.bay }})"+
+				" of logical drive {{ $labels.dev }}"+onNode+
+				" reports status {{ $labels.status }}."),
+
+		newAlertRule("RaidPhysicalDrivePredictiveFailure",
+			fmt.Sprintf(`%s{status="Predictive Failure"} > 0 or %s == 0`,
+				pdStatus, smartPassed), "15m", "warning",
+			"RAID physical drive predicted to fail",
+			"Physical drive {{ $labels.id }} (box {{ $labels.box }}, bay {{ $labels.bay }})"+
+				" of logical drive {{ $labels.dev }}"+onNode+
+				" reports predictive failure or failed SMART health check."),
+
+		newAlertRule("RaidSSDWearOut",
+			fmt.Sprintf("%s < %d", pdUsage, SSDUsageRemainingThreshold), "1h", "warning",
+			"RAID SSD physical drive is wearing out",
+			"SSD physical drive {{ $labels.id }} of logical drive {{ $labels.dev }}"+
+				onNode+" has {{ $value }}% of its endurance remaining."),
+
+		newAlertRule("RaidPhysicalDriveTemperatureHigh",
+			fmt.Sprintf("%s > %d", pdTemp, DriveTemperatureThreshold), "15m", "warning",
+			"RAID physical drive temperature is high",
+			"Physical drive {{ $labels.id }} of logical drive {{ $labels.dev }}"+
+				onNode+" temperature is {{ $value }}C."),
+
+		newAlertRule("HpessaExporterCollectorFailed",
+			fmt.Sprintf(`%s{collector=~"%s"} == 0`, scrapeSuccess,
+				strings.Join(alertedCollectors(), "|")), "30m", "warning",
+			"Storage devices exporter collector is failing",
+			"Collector {{ $labels.collector }} of the storage devices exporter"+
+				onNode+" fails to report metrics."),
+
+		newAlertRule("HpessaExporterDown",
+			fmt.Sprintf("up == 0 and on (job, instance) last_over_time(%s[1h])",
+				exporterInfo), "15m", "warning",
+			"Storage devices exporter is down",
+			"Storage devices exporter"+onNode+" failed to be scraped."),
+	}
+	if err := metrics.err(); err != nil {
+		return nil, err
+	}
+	return &AlertRuleGroups{
+		Groups: []AlertRuleGroup{
+			{Name: AlertRulesName, Rules: rules},
+		},
+	}, nil
+}
+
+// AlertRuleMetrics returns the names of the exporter's metrics which are
+// referenced by the alerting rules.
+func AlertRuleMetrics() ([]string, error) {
+	rules, err := AlertRules()
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, grp := range rules.Groups {
+		for _, rule := range grp.Rules {
+			for _, tok := range strings.FieldsFunc(rule.Expr, func(r rune) bool {
+				return !(r == '_' || r == ':' || ('a' <= r && r <= 'z') ||
+					('A' <= r && r <= 'Z') || ('0' <= r && r <= '9'))
+			}) {
+				if strings.HasPrefix(tok, collectorsNamespace+"_") &&
+					!containsString(ret, tok) {
+					ret = append(ret, tok)
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+// PrintAlertRules returns the alerting rules in YAML format, either as
+// PrometheusRule custom resource (format "prometheusrule") or as Prometheus
+// rules file (format "rules"), e.g. for promtool.
+func PrintAlertRules(format string) (string, error) {
+	rules, err := AlertRules()
+	if err != nil {
+		return "", err
+	}
+	var obj interface{}
+	switch format {
+	case "prometheusrule":
+		obj = &prometheusRule{
+			APIVersion: "monitoring.coreos.com/v1",
+			Kind:       "PrometheusRule",
+			Metadata: prometheusRuleMeta{
+				Name:      AlertRulesName,
+				Namespace: AlertRulesNamespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/name":    "hpessa-exporter",
+					"app.kubernetes.io/part-of": "openshift-storage-hpessa",
+				},
+			},
+			Spec: *rules,
+		}
+	case "rules":
+		obj = rules
+	default:
+		return "", fmt.Errorf("unknown rules format: %s", format)
+	}
+	dat, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return "---\n" + string(dat), nil
+}
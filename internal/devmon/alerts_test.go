@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestAlertRulesMetrics(t *testing.T) {
+	names := devmon.MetricNames()
+	metrics, err := devmon.AlertRuleMetrics()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, metrics)
+	for _, metric := range metrics {
+		assert.Contains(t, names, metric)
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	names := devmon.MetricNames()
+	assert.Contains(t, names, "hpessa_node_info")
+	assert.Contains(t, names, "hpessa_exporter_info")
+	assert.Contains(t, names, "hpessa_scrape_collector_success")
+	assert.Contains(t, names, "hpessa_raid_physical_device_smart_passed")
+	for _, name := range names {
+		assert.Regexp(t, "^hpessa_", name)
+	}
+}
+
+// TestAlertRulesGenerated ensures that the shipped rules are up to date with
+// the generated ones (see make generate-rules)
+func TestAlertRulesGenerated(t *testing.T) {
+	rules, err := devmon.AlertRules()
+	assert.NoError(t, err)
+
+	dat, err := os.ReadFile("testdata/alerts/rules.yaml")
+	assert.NoError(t, err)
+	groups := &devmon.AlertRuleGroups{}
+	assert.NoError(t, yaml.Unmarshal(dat, groups))
+	assert.Equal(t, rules, groups)
+
+	dat, err = os.ReadFile("../../config/prometheusrule.yaml")
+	assert.NoError(t, err)
+	prule := &struct {
+		Kind string                 `json:"kind"`
+		Spec devmon.AlertRuleGroups `json:"spec"`
+	}{}
+	assert.NoError(t, yaml.Unmarshal(dat, prule))
+	assert.Equal(t, "PrometheusRule", prule.Kind)
+	assert.Equal(t, rules, &prule.Spec)
+
+	_, err = devmon.PrintAlertRules("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	// InventoryAPIPath is the HTTP path of the JSON inventory endpoint
+	InventoryAPIPath = "/api/v1/inventory"
+	// InventoryAPIVersion is the version of the JSON inventory document;
+	// fields may be added within a version, but never renamed or removed
+	InventoryAPIVersion = "v1"
+)
+
+// InventoryNode is the identity of the node (and exporter's pod, if any)
+type InventoryNode struct {
+	Nodename  string `json:"nodename"`
+	Hostname  string `json:"hostname"`
+	HostIP    string `json:"hostip,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Kernel    string `json:"kernel"`
+	Machine   string `json:"machine"`
+}
+
+// InventoryBlockDevice is a block device of the node, with a reference to
+// the RAID logical drive which it exposes (if any).
+type InventoryBlockDevice struct {
+	BlkdevInfo
+	RaidBackend      string `json:"raidbackend,omitempty"`
+	RaidController   string `json:"raidcontroller,omitempty"`
+	RaidLogicalDrive string `json:"raidlogicaldrive,omitempty"`
+}
+
+// Inventory is the full normalized model of node's storage hardware, as
+// served by the inventory API.
+type Inventory struct {
+	Version         string                 `json:"version"`
+	ExporterVersion string                 `json:"exporterversion"`
+	Timestamp       time.Time              `json:"timestamp"`
+	Node            InventoryNode          `json:"node"`
+	Health          string                 `json:"health"`
+	Controllers     []RaidController       `json:"controllers"`
+	BlockDevices    []InventoryBlockDevice `json:"blockdevices"`
+}
+
+// NewInventory returns the inventory of node's RAID controllers (with their
+// arrays, logical and physical drives) and block devices.
+func NewInventory(node InventoryNode, ctrls []RaidController,
+	bdis []BlkdevInfo, now time.Time) *Inventory {
+	inv := &Inventory{
+		Version:         InventoryAPIVersion,
+		ExporterVersion: Version(),
+		Timestamp:       now.UTC(),
+		Node:            node,
+		Health:          NewRaidHealthSummary(ctrls).Health,
+		Controllers:     ctrls,
+		BlockDevices:    []InventoryBlockDevice{},
+	}
+	if inv.Controllers == nil {
+		inv.Controllers = []RaidController{}
+	}
+	for _, sdi := range newStorageDeviceInfo(bdis, ctrls) {
+		ibd := InventoryBlockDevice{BlkdevInfo: sdi.BlkdevInfo}
+		// empty lists rather than nulls, for the sake of consumers
+		if ibd.ByID == nil {
+			ibd.ByID = []string{}
+		}
+		if ibd.ByPath == nil {
+			ibd.ByPath = []string{}
+		}
+		if ibd.Udev == nil {
+			ibd.Udev = map[string]string{}
+		}
+		if sdi.LogicalDrive != nil {
+			ibd.RaidBackend = sdi.Controller.Backend
+			ibd.RaidController = sdi.Controller.ID
+			ibd.RaidLogicalDrive = sdi.LogicalDrive.ID
+		}
+		inv.BlockDevices = append(inv.BlockDevices, ibd)
+	}
+	return inv
+}
+
+// probeInventory probes node's block devices and RAID controllers
+func (sdp *storageDevicesProbe) probeInventory() (*Inventory, error) {
+	uname, err := Uname()
+	if err != nil {
+		return nil, err
+	}
+	bdis, err := sdp.probeBlockDevices()
+	if err != nil {
+		return nil, err
+	}
+	ctrls, err := sdp.probeRaidControllers()
+	if err != nil {
+		return nil, err
+	}
+	node := InventoryNode{
+		Nodename:  sdp.ident.Nodename,
+		Hostname:  sdp.ident.Hostname,
+		HostIP:    sdp.ident.HostIP,
+		Pod:       sdp.ident.Name,
+		Namespace: sdp.ident.Namespace,
+		Kernel:    uname.Release,
+		Machine:   uname.Machine,
+	}
+	return NewInventory(node, ctrls, bdis, time.Now()), nil
+}
+
+// serveInventory serves the JSON inventory of local node, once the exporter
+// is initialized.
+func (dex *deviceExporter) serveInventory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !dex.hlt.isInitialized() {
+		http.Error(w, "not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	inv, err := dex.sdp.probeInventory()
+	if err != nil {
+		dex.log.Error(err, "failed to probe inventory")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dat, err := json.Marshal(inv)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dat)
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInventory(t *testing.T) {
+	node := devmon.InventoryNode{
+		Nodename: "worker-0",
+		Hostname: "worker-0",
+		Kernel:   "4.18.0-305.el8.x86_64",
+		Machine:  "x86_64",
+	}
+	ctrls := []devmon.RaidController{newTestRaidController("OK", "OK", "Predictive Failure")}
+	bdis := []devmon.BlkdevInfo{
+		{Major: 8, Minor: 0, Name: "sda", Size: 1 << 40},
+		{Major: 259, Minor: 0, Name: "nvme0n1", Size: 1 << 39},
+	}
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	inv := devmon.NewInventory(node, ctrls, bdis, now)
+	assert.Equal(t, devmon.InventoryAPIVersion, inv.Version)
+	assert.Equal(t, devmon.RaidHealthDegraded, inv.Health)
+	assert.Equal(t, 1, len(inv.Controllers))
+	assert.Equal(t, 2, len(inv.BlockDevices))
+	assert.Equal(t, "ssacli", inv.BlockDevices[0].RaidBackend)
+	assert.Equal(t, "0", inv.BlockDevices[0].RaidController)
+	assert.Equal(t, "1", inv.BlockDevices[0].RaidLogicalDrive)
+	assert.Equal(t, "", inv.BlockDevices[1].RaidLogicalDrive)
+
+	dat, err := json.Marshal(inv)
+	assert.NoError(t, err)
+	doc := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(dat, &doc))
+	assert.Equal(t, "v1", doc["version"])
+	assert.Equal(t, "2022-03-01T12:00:00Z", doc["timestamp"])
+	assert.Equal(t, "worker-0", doc["node"].(map[string]interface{})["nodename"])
+	bdev := doc["blockdevices"].([]interface{})[1].(map[string]interface{})
+	assert.Equal(t, "nvme0n1", bdev["name"])
+	assert.Equal(t, []interface{}{}, bdev["byid"])
+	assert.NotContains(t, bdev, "raidlogicaldrive")
+
+	inv = devmon.NewInventory(node, nil, nil, now)
+	assert.Equal(t, devmon.RaidHealthOK, inv.Health)
+	dat, err = json.Marshal(inv)
+	assert.NoError(t, err)
+	assert.Contains(t, string(dat), `"controllers":[]`)
+	assert.Contains(t, string(dat), `"blockdevices":[]`)
+}
@@ -0,0 +1,457 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	arcconfSectionNone = iota
+	arcconfSectionController
+	arcconfSectionLogical
+	arcconfSectionPhysical
+)
+
+type ArcconfEntry struct {
+	Title  string
+	Values map[string]string
+}
+
+type ArcconfLogicalDevice struct {
+	ArcconfEntry
+	Members []string
+}
+
+type ArcconfPhysicalDevice struct {
+	ArcconfEntry
+	Channel string
+}
+
+// ArcconfConfigInfo represents the output of 'arcconf getconfig <N> al' for a
+// single controller.
+type ArcconfConfigInfo struct {
+	Controller      ArcconfEntry
+	LogicalDevices  []*ArcconfLogicalDevice
+	PhysicalDevices []*ArcconfPhysicalDevice
+}
+
+// arcconfMemberRe matches the members list entries of logical device, e.g.
+// 'Device 0 : Present (286102MB, SAS, HDD, Channel:0, Device:0) S0K4XYZ1'
+var arcconfMemberRe = regexp.MustCompile(`^(Device|Segment) [0-9]+$`)
+
+// arcconfStatusMap maps Adaptec states (of controllers, backup units, logical
+// devices and physical devices) to the status vocabulary of ssacli; states
+// which are not mapped are normalized to "Unknown".
+var arcconfStatusMap = map[string]string{
+	"Optimal":                        "OK",
+	"Okay":                           "OK",
+	"Ready":                          "OK",
+	"Online":                         "OK",
+	"Hot Spare":                      "OK",
+	"Global Hot-Spare":               "OK",
+	"Dedicated Hot-Spare":            "OK",
+	"Auto-Replace Hot-Spare":         "OK",
+	"Raw (Pass Through)":             "OK",
+	"Suboptimal":                     "Degraded",
+	"Suboptimal, Fault Tolerant":     "Degraded",
+	"Suboptimal, Not Fault Tolerant": "Degraded",
+	"Degraded":                       "Degraded",
+	"Impacted":                       "Degraded",
+	"Rebuilding":                     "Rebuilding",
+	"Degraded, Rebuilding":           "Rebuilding",
+	"Charging":                       "Recharging",
+	"Failed":                         "Failed",
+	"Missing":                        "Failed",
+	"Not Responding":                 "Failed",
+	"Offline":                        "Offline",
+	"Not Present":                    "",
+}
+
+func LocateArcconf() (string, error) {
+	knowns := []string{
+		"/usr/sbin/arcconf",
+		"/usr/bin/arcconf",
+		"/usr/Arcconf/arcconf",
+		"/opt/Adaptec/arcconf/arcconf",
+	}
+	return locateTool("arcconf", knowns)
+}
+
+func RunArcconfVersion(ctx context.Context) (string, error) {
+	// arcconf without command prints its usage, prefixed with version banner
+	// and exits with non-zero status
+	dat, err := executeArcconfCommand(ctx)
+	if err != nil && len(dat) == 0 {
+		return "", err
+	}
+	return ParseArcconfVersion(dat)
+}
+
+func ParseArcconfVersion(dat string) (string, error) {
+	for _, ln := range strings.Split(dat, "\n") {
+		if !strings.Contains(ln, "UCLI") {
+			continue
+		}
+		idx := strings.Index(ln, "Version ")
+		if idx > 0 {
+			return strings.TrimSpace(ln[idx+len("Version "):]), nil
+		}
+	}
+	return "", errors.New("failed to parse arcconf version")
+}
+
+// ParseArcconfControllersCount parses the number of controllers from the
+// output of 'arcconf getversion'.
+func ParseArcconfControllersCount(dat string) (int, error) {
+	for _, ln := range strings.Split(dat, "\n") {
+		key, val := arcconfKeyValueOf(ln)
+		if key == "Controllers found" {
+			return strconv.Atoi(val)
+		}
+	}
+	return 0, errors.New("failed to parse arcconf controllers count")
+}
+
+// RunArcconfShowAll queries the configuration of each of the controllers and
+// converts them into vendor-neutral representation.
+func RunArcconfShowAll(ctx context.Context) ([]RaidController, error) {
+	ret := []RaidController{}
+	dat, err := executeArcconfCommand(ctx, "getversion")
+	if err != nil {
+		return ret, err
+	}
+	cnt, err := ParseArcconfControllersCount(dat)
+	if err != nil {
+		return ret, err
+	}
+	for idx := 1; idx <= cnt; idx++ {
+		id := strconv.Itoa(idx)
+		dat, err = executeArcconfCommand(ctx, "getconfig", id, "al")
+		if err != nil {
+			return ret, err
+		}
+		cfg, err := ParseArcconfConfig(dat)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, ParseArcconfRaidController(id, cfg))
+	}
+	return ret, nil
+}
+
+func executeArcconfCommand(ctx context.Context, args ...string) (string, error) {
+	loc, err := LocateArcconf()
+	if err != nil {
+		return "", err
+	}
+	return executeCommand(ctx, loc, args...)
+}
+
+// ParseArcconfConfig parses the output of 'arcconf getconfig <N> al' into
+// its controller, logical devices and physical devices sections.
+func ParseArcconfConfig(dat string) (*ArcconfConfigInfo, error) {
+	cfg := &ArcconfConfigInfo{}
+	cfg.Controller.Values = map[string]string{}
+	section := arcconfSectionNone
+	hasController := false
+	channel := ""
+	var ld *ArcconfLogicalDevice
+	var pd *ArcconfPhysicalDevice
+	for _, line := range strings.Split(dat, "\n") {
+		ln := strings.TrimSpace(line)
+		if len(ln) == 0 || strings.HasPrefix(ln, "---") {
+			continue
+		}
+		if sec, ok := arcconfSectionOf(line); ok {
+			section = sec
+			hasController = hasController || sec == arcconfSectionController
+			ld = nil
+			pd = nil
+			continue
+		}
+		key, val := arcconfKeyValueOf(ln)
+		switch section {
+		case arcconfSectionController:
+			if _, ok := cfg.Controller.Values[key]; !ok && val != "" {
+				cfg.Controller.Values[key] = val
+			}
+		case arcconfSectionLogical:
+			if strings.HasPrefix(strings.ToLower(ln), "logical device number") {
+				ld = &ArcconfLogicalDevice{}
+				ld.Title = ln
+				ld.Values = map[string]string{}
+				cfg.LogicalDevices = append(cfg.LogicalDevices, ld)
+				continue
+			}
+			if ld == nil || val == "" {
+				continue
+			}
+			if arcconfMemberRe.MatchString(key) {
+				if serial := arcconfMemberSerial(val); serial != "" {
+					ld.Members = append(ld.Members, serial)
+				}
+			} else if _, ok := ld.Values[key]; !ok {
+				ld.Values[key] = val
+			}
+		case arcconfSectionPhysical:
+			switch {
+			case strings.HasPrefix(ln, "Channel #"):
+				channel = strings.TrimSuffix(strings.TrimPrefix(ln, "Channel #"), ":")
+				pd = nil
+			case strings.HasPrefix(ln, "Device #"):
+				pd = &ArcconfPhysicalDevice{Channel: channel}
+				pd.Title = ln
+				pd.Values = map[string]string{}
+				cfg.PhysicalDevices = append(cfg.PhysicalDevices, pd)
+			case pd != nil && strings.HasPrefix(ln, "Device is a"):
+				pd.Values["Device Type"] = strings.TrimSpace(
+					strings.TrimPrefix(strings.TrimPrefix(ln, "Device is an"), "Device is a"))
+			case pd != nil && val != "":
+				if _, ok := pd.Values[key]; !ok {
+					pd.Values[key] = val
+				}
+			}
+		}
+	}
+	if !hasController {
+		return nil, errors.New("failed to parse arcconf config: no controller information")
+	}
+	return cfg, nil
+}
+
+// arcconfSectionOf detects top-level section titles, which (unlike the
+// sub-sections titles) are not indented
+func arcconfSectionOf(line string) (int, bool) {
+	ln := strings.ToLower(strings.TrimSpace(line))
+	if countLeading(line, ' ') > 0 || strings.Contains(ln, ":") ||
+		!strings.HasSuffix(ln, " information") {
+		return arcconfSectionNone, false
+	}
+	switch ln {
+	case "controller information":
+		return arcconfSectionController, true
+	case "logical device information":
+		return arcconfSectionLogical, true
+	case "physical device information":
+		return arcconfSectionPhysical, true
+	}
+	return arcconfSectionNone, true
+}
+
+// arcconfKeyValueOf splits 'Key : Value' line; unlike ssacli, values may
+// contain ': ' (e.g. 'Disk Name : /dev/sda (Disk0) (Bus: 1, Target: 0, Lun: 0)')
+func arcconfKeyValueOf(ln string) (string, string) {
+	kv := strings.SplitN(strings.TrimSpace(ln), ": ", 2)
+	if len(kv) != 2 {
+		return strings.TrimSpace(ln), ""
+	}
+	return strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+}
+
+func arcconfMemberSerial(val string) string {
+	idx := strings.LastIndex(val, ")")
+	if idx < 0 {
+		return ""
+	}
+	return strings.TrimSpace(val[idx+1:])
+}
+
+// ParseArcconfRaidController converts parsed arcconf config into
+// vendor-neutral representation of RAID controller. Logical devices are
+// grouped into arrays by their array number (when reported), or each forms
+// an array of its own.
+func ParseArcconfRaidController(id string, cfg *ArcconfConfigInfo) RaidController {
+	cvals := cfg.Controller.Values
+	ctrl := RaidController{}
+	ctrl.ID = id
+	ctrl.Model = cvals["Controller Model"]
+	ctrl.Serial = cvals["Controller Serial Number"]
+	ctrl.Firmware = arcconfFirstField(cvals["Firmware"])
+	ctrl.Status = arcconfStatus(cvals["Controller Status"])
+	ctrl.Temperature = parseTempOr(strings.Split(cvals["Temperature"], "C")[0], -1)
+	if status, ok := cvals["Overall Backup Unit Status"]; ok {
+		ctrl.BatteryStatus = arcconfStatus(status)
+	}
+
+	pds := map[string]RaidPhysicalDrive{}
+	for _, apd := range cfg.PhysicalDevices {
+		if !strings.Contains(strings.ToLower(apd.Values["Device Type"]), "drive") {
+			continue
+		}
+		pd := arcconfPhysicalDriveToRaid(apd)
+		if pd.Serial != "" {
+			pds[pd.Serial] = pd
+		}
+	}
+
+	arrs := map[string]*RaidArray{}
+	for _, ald := range cfg.LogicalDevices {
+		ld := arcconfLogicalDriveToRaid(ald)
+		for _, serial := range ald.Members {
+			if pd, ok := pds[serial]; ok {
+				ld.PhysicalDrives = append(ld.PhysicalDrives, pd)
+			}
+		}
+		arr, ok := arrs[ld.ArrayName]
+		if !ok {
+			arr = &RaidArray{Name: ld.ArrayName}
+			arrs[ld.ArrayName] = arr
+		}
+		arr.LogicalDrives = append(arr.LogicalDrives, ld)
+		arr.PhysicalDrives = appendRaidPhysicalDrives(arr.PhysicalDrives, ld.PhysicalDrives)
+	}
+	names := []string{}
+	for name := range arrs {
+		names = append(names, name)
+	}
+	for _, name := range sortRaidKeys(names) {
+		arr := arrs[name]
+		arr.Status = raidArrayStatus(arr)
+		ctrl.Arrays = append(ctrl.Arrays, *arr)
+	}
+	return ctrl
+}
+
+func arcconfLogicalDriveToRaid(ald *ArcconfLogicalDevice) RaidLogicalDrive {
+	ld := RaidLogicalDrive{}
+	ld.ID = strings.TrimSpace(ald.Title[len("logical device number"):])
+	ld.DiskName = arcconfFirstField(ald.Values["Disk Name"])
+	ld.SizeBytes = parseSizeBytes(ald.Values["Size"])
+	ld.Status = arcconfStatus(ald.Values["Status of Logical Device"])
+	ld.UniqueID = arcconfUniqueID(ald.Values["Unique Identifier"])
+	ld.RaidLevel = ald.Values["RAID level"]
+	ld.ArrayName = ald.Values["Array"]
+	if ld.ArrayName == "" {
+		ld.ArrayName = ld.ID
+	}
+	return ld
+}
+
+func arcconfPhysicalDriveToRaid(apd *ArcconfPhysicalDevice) RaidPhysicalDrive {
+	vals := apd.Values
+	pd := RaidPhysicalDrive{}
+	pd.ID = strings.Split(vals["Reported Channel,Device(T:L)"], "(")[0]
+	if pd.ID == "" {
+		pd.ID = fmt.Sprintf("%s,%s", apd.Channel, strings.TrimPrefix(apd.Title, "Device #"))
+	}
+	pd.Box, pd.Bay = arcconfLocationOf(vals["Reported Location"])
+	pd.Model = strings.Join(strings.Fields(vals["Vendor"]+" "+vals["Model"]), " ")
+	pd.Serial = vals["Serial number"]
+	pd.Firmware = vals["Firmware"]
+	pd.Interface = arcconfFirstField(vals["Transfer Speed"])
+	pd.MediaType = "HDD"
+	if vals["SSD"] == "Yes" {
+		pd.MediaType = "SSD"
+	}
+	pd.SizeBytes = parseSizeBytes(vals["Total Size"])
+	pd.Status = arcconfStatus(vals["State"])
+	if pd.Status == "OK" && (vals["S.M.A.R.T."] == "Yes" ||
+		parseInt64(vals["S.M.A.R.T. warnings"]) > 0) {
+		pd.Status = "Predictive Failure"
+	}
+	pd.UniqueID = vals["World-wide name"]
+	pd.TempCurr = parseTempOr(strings.Split(vals["Temperature"], "C")[0], -1)
+	pd.TempMaxi = -1
+	pd.PowerHours = -1
+	pd.UsageRemaining = -1
+	return pd
+}
+
+// arcconfLocationOf parses 'Enclosure 0, Slot 3' into box and bay
+func arcconfLocationOf(loc string) (string, string) {
+	box := ""
+	bay := ""
+	for _, part := range strings.Split(loc, ",") {
+		kv := strings.Fields(part)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Enclosure":
+			box = kv[1]
+		case "Slot":
+			bay = kv[1]
+		}
+	}
+	return box, bay
+}
+
+// arcconfFirstField returns the first word of a value (e.g. "/dev/sda" of
+// "/dev/sda (Disk0) (Bus: 1, Target: 0, Lun: 0)"), or empty string when the
+// value is missing.
+func arcconfFirstField(val string) string {
+	fields := strings.Fields(val)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// arcconfUniqueID returns the logical device's unique identifier only if it
+// is a WWN; older controllers report a short (32 bits) volume identifier
+// which can not be matched against block devices.
+func arcconfUniqueID(uid string) string {
+	if len(NormalizeWWN(uid)) < 16 {
+		return ""
+	}
+	return uid
+}
+
+func arcconfStatus(state string) string {
+	state = strings.TrimSpace(state)
+	if state == "" {
+		return ""
+	}
+	if status, ok := arcconfStatusMap[state]; ok {
+		return status
+	}
+	return "Unknown"
+}
+
+func appendRaidPhysicalDrives(pds, more []RaidPhysicalDrive) []RaidPhysicalDrive {
+	ids := map[string]bool{}
+	for _, pd := range pds {
+		ids[pd.ID] = true
+	}
+	for _, pd := range more {
+		if !ids[pd.ID] {
+			ids[pd.ID] = true
+			pds = append(pds, pd)
+		}
+	}
+	return pds
+}
+
+// arcconfBackend is the RAID backend of Microchip (Adaptec) SmartRAID
+// controllers, based on arcconf tool.
+type arcconfBackend struct{}
+
+func newArcconfBackend() *arcconfBackend {
+	return &arcconfBackend{}
+}
+
+func (*arcconfBackend) Name() string {
+	return "arcconf"
+}
+
+func (*arcconfBackend) Vendor() string {
+	return "microchip"
+}
+
+func (*arcconfBackend) Detect() error {
+	_, err := LocateArcconf()
+	return err
+}
+
+func (*arcconfBackend) Version(ctx context.Context) (string, error) {
+	return RunArcconfVersion(ctx)
+}
+
+func (*arcconfBackend) Probe(ctx context.Context) ([]RaidController, error) {
+	return RunArcconfShowAll(ctx)
+}
@@ -0,0 +1,483 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+var arcconfVersion1 = `
+Controllers found: 1
+ | UCLI |  Microsemi Adaptec Command Line Interface
+ | UCLI |  Version 3.01 (B23531)
+ | UCLI |  (C) Microsemi Corporation 2003-2018. All Rights Reserved.
+`
+
+var arcconfGetversion1 = `
+Controllers found: 1
+Controller #1
+==============
+Firmware                               : 7.5-0 (32118)
+Staged Firmware                        : 7.5-0 (32118)
+BIOS                                   : 7.5-0 (32118)
+Driver                                 : 1.2-1 (41010)
+Boot Flash                             : 7.5-0 (32118)
+
+Command completed successfully.
+`
+
+var arcconfGetconfigAl1 = `
+Controllers found: 1
+----------------------------------------------------------------------
+Controller information
+----------------------------------------------------------------------
+   Controller Status                          : Optimal
+   Controller Mode                            : RAID (Expose RAW)
+   Channel description                        : SAS/SATA
+   Controller Model                           : Adaptec ASR8805
+   Controller Serial Number                   : 7A2161EFCF1
+   Controller World Wide Name                 : 50000D1107A54E80
+   Physical Slot                              : 1
+   Temperature                                : 51 C/ 123 F (Normal)
+   Installed memory                           : 1024 MB
+   Copyback                                   : Disabled
+   Background consistency check               : Disabled
+   Automatic Failover                         : Enabled
+   Global task priority                       : High
+   Performance Mode                           : Default/Dynamic
+   Host bus type                              : PCIe 3.0
+   Host bus speed                             : 7880 MBps
+   Host bus link width                        : 8 bit(s)/link(s)
+   Stayawake period                           : Disabled
+   Spinup limit internal drives               : 0
+   Spinup limit external drives               : 0
+   Defunct disk drive count                   : 0
+   Logical devices/Failed/Degraded            : 2/0/1
+   NCQ status                                 : Enabled
+   Statistics data collection mode            : Enabled
+   Global Physical Device Write Cache Policy  : Drive Specific
+   --------------------------------------------------------
+   Controller Version Information
+   --------------------------------------------------------
+   BIOS                                       : 7.5-0 (32118)
+   Firmware                                   : 7.5-0 (32118)
+   Driver                                     : 1.2-1 (41010)
+   Boot Flash                                 : 7.5-0 (32118)
+   CPLD (Load version/ Default version)       : 7/ 7
+   SEEPROM (Load version/ Default version)    : 1/ 1
+   --------------------------------------------------------
+   Controller Cache Backup Unit Information
+   --------------------------------------------------------
+
+    Overall Backup Unit Status                : Ready
+
+    Backup unit Type                          : AFM-700
+    Supercap Status                           : Ready
+   --------------------------------------------------------
+   Temperature Sensors Information
+   --------------------------------------------------------
+   Sensor ID                                  : 0
+   Current Value                              : 41 deg C
+   Max Value Since Powered On                 : 46 deg C
+   Location                                   : Inlet Ambient
+
+----------------------------------------------------------------------
+Logical device information
+----------------------------------------------------------------------
+Logical Device number 0
+   Logical Device name                        : os
+   Block Size of member drives                : 512 Bytes
+   RAID level                                 : 1
+   Unique Identifier                          : 0DE3F3A7
+   Status of Logical Device                   : Optimal
+   Size                                       : 285686 MB
+   Parity space                               : 285696 MB
+   Interface Type                             : Serial Attached SCSI
+   Device Type                                : HDD
+   Read-cache setting                         : Enabled
+   Read-cache status                          : On
+   Write-cache setting                        : Enabled
+   Write-cache status                         : On
+   Partitioned                                : Yes
+   Protected by Hot-Spare                     : No
+   Bootable                                   : Yes
+   Failed stripes                             : No
+   Power settings                             : Disabled
+   Disk Name                                  : /dev/sda (Disk0) (Bus: 1, Target: 0, Lun: 0)
+   --------------------------------------------------------
+   Logical Device segment information
+   --------------------------------------------------------
+   Group 0, Segment 0                         : Present (286102MB, SAS, HDD, Enclosure:0, Slot:0) S0K2J0DW
+   Group 0, Segment 1                         : Present (286102MB, SAS, HDD, Enclosure:0, Slot:1) S0K2J1BE
+   Segment 0                                  : Present (286102MB, SAS, HDD, Enclosure:0, Slot:0) S0K2J0DW
+   Segment 1                                  : Present (286102MB, SAS, HDD, Enclosure:0, Slot:1) S0K2J1BE
+
+Logical Device number 1
+   Logical Device name                        : data
+   Block Size of member drives                : 512 Bytes
+   RAID level                                 : 5
+   Unique Identifier                          : 600508B1001C4D6E09A8F3B27C5D1E48
+   Status of Logical Device                   : Degraded
+   Size                                       : 1143296 MB
+   Interface Type                             : Serial Attached SCSI
+   Disk Name                                  : /dev/sdb (Disk1) (Bus: 1, Target: 1, Lun: 0)
+   --------------------------------------------------------
+   Logical Device segment information
+   --------------------------------------------------------
+   Segment 0                                  : Present (572325MB, SAS, HDD, Enclosure:0, Slot:2) W0M1T4PZ
+   Segment 1                                  : Present (572325MB, SAS, HDD, Enclosure:0, Slot:3) W0M1T5C8
+   Segment 2                                  : Missing
+
+
+----------------------------------------------------------------------
+Physical Device information
+----------------------------------------------------------------------
+      Channel #0:
+         Device #0
+            Device is a Hard drive
+            State                              : Online
+            Block Size                         : 512 Bytes
+            Supported                          : Yes
+            Programmed Max Speed               : SAS 12.0 Gb/s
+            Transfer Speed                     : SAS 12.0 Gb/s
+            Reported Channel,Device(T:L)       : 0,0(0:0)
+            Reported Location                  : Enclosure 0, Slot 0
+            Reported ESD(T:L)                  : 2,0(0:0)
+            Vendor                             : SEAGATE
+            Model                              : ST300MM0048
+            Firmware                           : N004
+            Serial number                      : S0K2J0DW
+            World-wide name                    : 5000C5009E41A0F7
+            Reserved Size                      : 956312 KB
+            Used Size                          : 285696 MB
+            Unused Size                        : 64 KB
+            Total Size                         : 286102 MB
+            Write Cache                        : Enabled (write-back)
+            FRU                                : None
+            S.M.A.R.T.                         : No
+            S.M.A.R.T. warnings                : 0
+            Power State                        : Full rpm
+            Supported Power States             : Full rpm,Powered off
+            SSD                                : No
+            Temperature                        : 33 C/ 91 F
+         Device #1
+            Device is a Hard drive
+            State                              : Online
+            Transfer Speed                     : SAS 12.0 Gb/s
+            Reported Channel,Device(T:L)       : 0,1(1:0)
+            Reported Location                  : Enclosure 0, Slot 1
+            Vendor                             : SEAGATE
+            Model                              : ST300MM0048
+            Firmware                           : N004
+            Serial number                      : S0K2J1BE
+            World-wide name                    : 5000C5009E41B36B
+            Total Size                         : 286102 MB
+            S.M.A.R.T.                         : No
+            S.M.A.R.T. warnings                : 2
+            SSD                                : No
+            Temperature                        : 35 C/ 95 F
+         Device #2
+            Device is a Hard drive
+            State                              : Online
+            Transfer Speed                     : SAS 12.0 Gb/s
+            Reported Channel,Device(T:L)       : 0,2(2:0)
+            Reported Location                  : Enclosure 0, Slot 2
+            Vendor                             : SEAGATE
+            Model                              : ST600MM0208
+            Firmware                           : N003
+            Serial number                      : W0M1T4PZ
+            World-wide name                    : 5000C500B3F1E2A9
+            Total Size                         : 572325 MB
+            S.M.A.R.T.                         : No
+            SSD                                : No
+            Temperature                        : 31 C/ 87 F
+         Device #3
+            Device is a Hard drive
+            State                              : Online
+            Transfer Speed                     : SAS 12.0 Gb/s
+            Reported Channel,Device(T:L)       : 0,3(3:0)
+            Reported Location                  : Enclosure 0, Slot 3
+            Vendor                             : SEAGATE
+            Model                              : ST600MM0208
+            Firmware                           : N003
+            Serial number                      : W0M1T5C8
+            World-wide name                    : 5000C500B3F1F465
+            Total Size                         : 572325 MB
+            S.M.A.R.T.                         : No
+            SSD                                : No
+            Temperature                        : 32 C/ 89 F
+      Channel #2:
+         Device #0
+            Device is an Enclosure Services Device
+            Reported Channel,Device(T:L)       : 2,0(0:0)
+            Enclosure ID                       : 0
+            Type                               : SES2
+            Vendor                             : ADAPTEC
+            Model                              : Virtual SGPIO
+            Firmware                           : 0001
+
+----------------------------------------------------------------------
+Connector information
+----------------------------------------------------------------------
+Device #0
+   Connector name                             : Connector 0
+   Functional Mode                            : Default
+
+
+Command completed successfully.
+`
+
+func TestParseArcconfVersion(t *testing.T) {
+	vers, err := devmon.ParseArcconfVersion(arcconfVersion1)
+	assert.NoError(t, err)
+	assert.Equal(t, vers, "3.01 (B23531)")
+
+	cnt, err := devmon.ParseArcconfControllersCount(arcconfGetversion1)
+	assert.NoError(t, err)
+	assert.Equal(t, cnt, 1)
+
+	_, err = devmon.ParseArcconfVersion(arcconfGetversion1)
+	assert.Error(t, err)
+}
+
+func TestParseArcconfConfig(t *testing.T) {
+	cfg, err := devmon.ParseArcconfConfig(arcconfGetconfigAl1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cfg.LogicalDevices), 2)
+	assert.Equal(t, len(cfg.PhysicalDevices), 5)
+	assert.Equal(t, cfg.Controller.Values["Firmware"], "7.5-0 (32118)")
+	assert.Equal(t, cfg.LogicalDevices[0].Members,
+		[]string{"S0K2J0DW", "S0K2J1BE"})
+
+	ctrl := devmon.ParseArcconfRaidController("1", cfg)
+	assert.Equal(t, ctrl.ID, "1")
+	assert.Equal(t, ctrl.Model, "Adaptec ASR8805")
+	assert.Equal(t, ctrl.Serial, "7A2161EFCF1")
+	assert.Equal(t, ctrl.Firmware, "7.5-0")
+	assert.Equal(t, ctrl.Status, "OK")
+	assert.Equal(t, ctrl.BatteryStatus, "OK")
+	assert.Equal(t, ctrl.Temperature, int64(51))
+	assert.Equal(t, len(ctrl.Arrays), 2)
+
+	ld0 := ctrl.Arrays[0].LogicalDrives[0]
+	assert.Equal(t, ld0.ID, "0")
+	assert.Equal(t, ld0.DiskName, "/dev/sda")
+	assert.Equal(t, ld0.UniqueID, "")
+	assert.Equal(t, ld0.RaidLevel, "1")
+	assert.Equal(t, ld0.Status, "OK")
+	assert.Equal(t, ld0.SizeBytes, uint64(285686*devmon.Mega))
+	assert.Equal(t, len(ld0.PhysicalDrives), 2)
+	assert.Equal(t, ctrl.Arrays[0].Status, "OK")
+
+	pd0 := ld0.PhysicalDrives[0]
+	assert.Equal(t, pd0.ID, "0,0")
+	assert.Equal(t, pd0.Box, "0")
+	assert.Equal(t, pd0.Bay, "0")
+	assert.Equal(t, pd0.Model, "SEAGATE ST300MM0048")
+	assert.Equal(t, pd0.Serial, "S0K2J0DW")
+	assert.Equal(t, pd0.Firmware, "N004")
+	assert.Equal(t, pd0.UniqueID, "5000C5009E41A0F7")
+	assert.Equal(t, pd0.Interface, "SAS")
+	assert.Equal(t, pd0.MediaType, "HDD")
+	assert.Equal(t, pd0.Status, "OK")
+	assert.Equal(t, pd0.TempCurr, int64(33))
+	assert.Equal(t, ld0.PhysicalDrives[1].Status, "Predictive Failure")
+
+	arr1 := ctrl.Arrays[1]
+	assert.Equal(t, arr1.Status, "Degraded")
+	ld1 := arr1.LogicalDrives[0]
+	assert.Equal(t, ld1.DiskName, "/dev/sdb")
+	assert.Equal(t, ld1.UniqueID, "600508B1001C4D6E09A8F3B27C5D1E48")
+	assert.Equal(t, len(ld1.PhysicalDrives), 2)
+
+	bdi := devmon.BlkdevInfo{Name: "sda", WWN: "6d0946606ad6c7002314c4f20b0c6c35"}
+	_, ld := devmon.LookupRaidLogicalDrive([]devmon.RaidController{ctrl}, &bdi)
+	assert.NotNil(t, ld)
+	assert.Equal(t, ld.ID, "0")
+
+	_, err = devmon.ParseArcconfConfig(arcconfGetversion1)
+	assert.Error(t, err)
+}
+
+// arcconf of a controller with a failed logical device: no firmware version,
+// no disk name of the (offline) logical device, and no transfer speed of the
+// failed drive are reported.
+var arcconfGetconfigAl2 = `
+Controllers found: 1
+----------------------------------------------------------------------
+Controller information
+----------------------------------------------------------------------
+   Controller Status                          : Optimal
+   Controller Mode                            : RAID (Expose RAW)
+   Channel description                        : SAS/SATA
+   Controller Model                           : Adaptec ASR8405
+   Controller Serial Number                   : 7A4161E2B08
+   Controller World Wide Name                 : 50000D1106B2F900
+   Physical Slot                              : 3
+   Temperature                                : 48 C/ 118 F (Normal)
+   Logical devices/Failed/Degraded            : 1/1/0
+
+----------------------------------------------------------------------
+Logical device information
+----------------------------------------------------------------------
+Logical Device number 0
+   Logical Device name                        : scratch
+   RAID level                                 : 0
+   Unique Identifier                          : 2C6E91B4
+   Status of Logical Device                   : Failed
+   Size                                       : 953837 MB
+   --------------------------------------------------------
+   Logical Device segment information
+   --------------------------------------------------------
+   Segment 0                                  : Present (953869MB, SATA, HDD, Enclosure:0, Slot:0) Z1W4K2QF
+   Segment 1                                  : Missing (953869MB, SATA, HDD, Enclosure:0, Slot:1) Z1W4K3M9
+
+
+----------------------------------------------------------------------
+Physical Device information
+----------------------------------------------------------------------
+      Channel #0:
+         Device #0
+            Device is a Hard drive
+            State                              : Online
+            Transfer Speed                     : SATA 6.0 Gb/s
+            Reported Channel,Device(T:L)       : 0,0(0:0)
+            Reported Location                  : Enclosure 0, Slot 0
+            Vendor                             : ATA
+            Model                              : ST1000NM0033-9ZM
+            Firmware                           : SN06
+            Serial number                      : Z1W4K2QF
+            Total Size                         : 953869 MB
+            SSD                                : No
+         Device #1
+            Device is a Hard drive
+            State                              : Failed
+            Reported Channel,Device(T:L)       : 0,1(1:0)
+            Reported Location                  : Enclosure 0, Slot 1
+            Vendor                             : ATA
+            Model                              : ST1000NM0033-9ZM
+            Serial number                      : Z1W4K3M9
+            SSD                                : No
+
+Command completed successfully.
+`
+
+func TestParseArcconfConfigMissingKeys(t *testing.T) {
+	cfg, err := devmon.ParseArcconfConfig(arcconfGetconfigAl2)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cfg.LogicalDevices), 1)
+	assert.Equal(t, len(cfg.PhysicalDevices), 2)
+
+	ctrl := devmon.ParseArcconfRaidController("1", cfg)
+	assert.Equal(t, ctrl.Model, "Adaptec ASR8405")
+	assert.Equal(t, ctrl.Firmware, "")
+	assert.Equal(t, ctrl.BatteryStatus, "")
+	assert.Equal(t, len(ctrl.Arrays), 1)
+
+	ld0 := ctrl.Arrays[0].LogicalDrives[0]
+	assert.Equal(t, ld0.DiskName, "")
+	assert.Equal(t, ld0.Status, "Failed")
+	assert.Equal(t, len(ld0.PhysicalDrives), 2)
+	assert.Equal(t, ld0.PhysicalDrives[0].Interface, "SATA")
+	assert.Equal(t, ld0.PhysicalDrives[0].UniqueID, "")
+	assert.Equal(t, ld0.PhysicalDrives[0].TempCurr, int64(-1))
+
+	pd1 := ld0.PhysicalDrives[1]
+	assert.Equal(t, pd1.Serial, "Z1W4K3M9")
+	assert.Equal(t, pd1.Interface, "")
+	assert.Equal(t, pd1.Firmware, "")
+	assert.Equal(t, pd1.Status, "Failed")
+}
+
+// arcconf of a controller with one logical device of a single drive, whose
+// states (and that of the backup unit) vary
+var arcconfGetconfigStatesFmt = `
+Controllers found: 1
+----------------------------------------------------------------------
+Controller information
+----------------------------------------------------------------------
+   Controller Status                          : %s
+   Controller Model                           : Adaptec ASR8405
+   Controller Serial Number                   : 7A4161E2B08
+   --------------------------------------------------------
+   Controller Cache Backup Unit Information
+   --------------------------------------------------------
+    Overall Backup Unit Status                : %s
+
+----------------------------------------------------------------------
+Logical device information
+----------------------------------------------------------------------
+Logical Device number 0
+   Logical Device name                        : data
+   RAID level                                 : 1
+   Status of Logical Device                   : %s
+   Size                                       : 953837 MB
+   --------------------------------------------------------
+   Logical Device segment information
+   --------------------------------------------------------
+   Segment 0                                  : Present (953869MB, SATA, HDD, Enclosure:0, Slot:0) Z1W4K2QF
+
+
+----------------------------------------------------------------------
+Physical Device information
+----------------------------------------------------------------------
+      Channel #0:
+         Device #0
+            Device is a Hard drive
+            State                              : %s
+            Reported Channel,Device(T:L)       : 0,0(0:0)
+            Reported Location                  : Enclosure 0, Slot 0
+            Vendor                             : ATA
+            Model                              : ST1000NM0033-9ZM
+            Serial number                      : Z1W4K2QF
+            SSD                                : No
+
+Command completed successfully.
+`
+
+func TestParseArcconfStates(t *testing.T) {
+	cases := []struct {
+		state  string
+		status string
+	}{
+		{"Optimal", "OK"},
+		{"Okay", "OK"},
+		{"Ready", "OK"},
+		{"Online", "OK"},
+		{"Hot Spare", "OK"},
+		{"Global Hot-Spare", "OK"},
+		{"Dedicated Hot-Spare", "OK"},
+		{"Auto-Replace Hot-Spare", "OK"},
+		{"Raw (Pass Through)", "OK"},
+		{"Suboptimal", "Degraded"},
+		{"Suboptimal, Fault Tolerant", "Degraded"},
+		{"Suboptimal, Not Fault Tolerant", "Degraded"},
+		{"Degraded", "Degraded"},
+		{"Impacted", "Degraded"},
+		{"Rebuilding", "Rebuilding"},
+		{"Degraded, Rebuilding", "Rebuilding"},
+		{"Charging", "Recharging"},
+		{"Failed", "Failed"},
+		{"Missing", "Failed"},
+		{"Not Responding", "Failed"},
+		{"Offline", "Offline"},
+		{"Not Present", ""},
+		{"Erasing", "Unknown"},
+	}
+	for _, tc := range cases {
+		dat := fmt.Sprintf(arcconfGetconfigStatesFmt, tc.state, tc.state, tc.state, tc.state)
+		cfg, err := devmon.ParseArcconfConfig(dat)
+		assert.NoError(t, err, tc.state)
+		ctrl := devmon.ParseArcconfRaidController("1", cfg)
+		assert.Equal(t, tc.status, ctrl.Status, tc.state)
+		assert.Equal(t, tc.status, ctrl.BatteryStatus, tc.state)
+		assert.Equal(t, 1, len(ctrl.Arrays), tc.state)
+		ld0 := ctrl.Arrays[0].LogicalDrives[0]
+		assert.Equal(t, tc.status, ld0.Status, tc.state)
+		assert.Equal(t, 1, len(ld0.PhysicalDrives), tc.state)
+		assert.Equal(t, tc.status, ld0.PhysicalDrives[0].Status, tc.state)
+	}
+}
@@ -1,60 +1,90 @@
 // SPDX-License-Identifier: Apache-2.0
 package devmon
 
-type BlkdevMap struct {
-	IDs     map[string]BlkdevID
-	IOStats map[string]*BlkdevIOStat
-	QStats  map[string]*BlkdevQueueStats
-}
+import (
+	"path/filepath"
+	"strings"
+)
 
-func DiscoverBlkdevStats(procfs *ProcFS, sysfs *SysFS) (*BlkdevMap, error) {
-	bdm := &BlkdevMap{
-		IDs:     map[string]BlkdevID{},
-		IOStats: map[string]*BlkdevIOStat{},
-		QStats:  map[string]*BlkdevQueueStats{},
-	}
+func DiscoverBlkdevInfo(procfs *ProcFS, sysfs *SysFS) ([]BlkdevInfo, error) {
+	ret := []BlkdevInfo{}
 	disks, err := procfs.DiskStats()
 	if err != nil {
-		return bdm, err
+		return ret, err
 	}
 	for _, dio := range disks {
 		name := dio.DeviceName
-		bdm.IDs[name] = dio.BlkdevID
-
 		isblk, _ := sysfs.IsBlock(name)
 		if !isblk {
 			continue
 		}
-
-		stat, err := sysfs.BlockStat(name)
+		bdi, err := sysfs.BlkdevInfo(name)
 		if err == nil {
-			bdm.IOStats[name] = stat
+			ret = append(ret, *bdi)
 		}
+	}
+	return ret, nil
+}
 
-		qstat, err := sysfs.BlockQueueStats(name)
+// ResolveBlkdevLinks attaches the persistent /dev/disk/by-id and
+// /dev/disk/by-path link names to each of the given block devices.
+func ResolveBlkdevLinks(devfs *DevFS, bdis []BlkdevInfo) []BlkdevInfo {
+	byid, _ := devfs.DiskLinks("by-id")
+	bypath, _ := devfs.DiskLinks("by-path")
+	for i := range bdis {
+		bdis[i].ByID = byid[bdis[i].Name]
+		bdis[i].ByPath = bypath[bdis[i].Name]
+	}
+	return bdis
+}
+
+// ResolveBlkdevUdev attaches udev properties to each of the given block
+// devices, if available from udev database.
+func ResolveBlkdevUdev(udevfs *UdevFS, bdis []BlkdevInfo) []BlkdevInfo {
+	for i := range bdis {
+		props, err := udevfs.BlockDeviceProperties(bdis[i].Major, bdis[i].Minor)
 		if err == nil {
-			bdm.QStats[name] = qstat
+			bdis[i].Udev = props
 		}
 	}
-	return bdm, nil
+	return bdis
 }
 
-func DiscoverBlkdevInfo(procfs *ProcFS, sysfs *SysFS) ([]BlkdevInfo, error) {
-	ret := []BlkdevInfo{}
-	disks, err := procfs.DiskStats()
-	if err != nil {
-		return ret, err
-	}
-	for _, dio := range disks {
-		name := dio.DeviceName
-		isblk, _ := sysfs.IsBlock(name)
-		if !isblk {
+// DiscoverBlkdevMounts maps mounted file-systems onto block devices by their
+// device numbers. File-systems which do not report a real device number (e.g.
+// btrfs) are resolved via their source device. Bind mounts (e.g. ostree's /
+// and /var, or btrfs subvolumes) map onto the device of their file-system;
+// duplicated mount points are ignored. File-system statistics are taken via
+// mount points relative to rootfs, the path of host's root directory.
+func DiscoverBlkdevMounts(sysfs *SysFS, mounts []MountInfo,
+	rootfs string) ([]BlkdevMountInfo, error) {
+	ret := []BlkdevMountInfo{}
+	seen := map[string]bool{}
+	for _, mi := range mounts {
+		if seen[mi.MountPoint] {
 			continue
 		}
-		bdi, err := sysfs.BlkdevInfo(name)
-		if err == nil {
-			ret = append(ret, *bdi)
+		major, minor := mi.Major, mi.Minor
+		if major == 0 {
+			if !strings.HasPrefix(mi.Source, "/dev/") {
+				continue
+			}
+			var err error
+			major, minor, err = sysfs.BlockDeviceNumberOf(mi.Source)
+			if err != nil {
+				continue
+			}
+		}
+		name, err := sysfs.BlockDeviceOf(major, minor)
+		if err != nil {
+			continue
+		}
+		seen[mi.MountPoint] = true
+		bmi := BlkdevMountInfo{MountInfo: mi, DeviceName: name}
+		if stfs, err := Statfs(filepath.Join(rootfs, mi.MountPoint)); err == nil {
+			bmi.SysStatfs = *stfs
 		}
+		ret = append(ret, bmi)
 	}
 	return ret, nil
 }
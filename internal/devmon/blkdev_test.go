@@ -8,19 +8,6 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestDiscoverBlockDevices(t *testing.T) {
-	bdm, err := devmon.DiscoverBlkdevStats(devmon.NewProcFS(), devmon.NewSysFS())
-	assert.NoError(t, err)
-	assert.NotNil(t, bdm)
-	assert.Greater(t, len(bdm.IDs), 0)
-	assert.Greater(t, len(bdm.IOStats), 0)
-	assert.Greater(t, len(bdm.QStats), 0)
-	for _, dev := range bdm.IDs {
-		assert.Greater(t, len(dev.DeviceName), 0)
-		assert.Greater(t, dev.MajorNumber, uint32(0))
-	}
-}
-
 func TestDiscoverBlkdevInfo(t *testing.T) {
 	bdi, err := devmon.DiscoverBlkdevInfo(devmon.NewProcFS(), devmon.NewSysFS())
 	assert.NoError(t, err)
@@ -30,3 +17,34 @@ func TestDiscoverBlkdevInfo(t *testing.T) {
 		assert.Greater(t, di.Major, uint32(0))
 	}
 }
+
+func TestDiscoverBlkdevMounts(t *testing.T) {
+	mounts, err := devmon.NewProcFSAt("testdata/mounts/proc").MountInfo("1")
+	assert.NoError(t, err)
+	sysfs := devmon.NewSysFSAt("testdata/mounts/sys")
+	bmis, err := devmon.DiscoverBlkdevMounts(sysfs, mounts, "testdata/mounts/rootfs")
+	assert.NoError(t, err)
+	devs := map[string]string{}
+	for _, bmi := range bmis {
+		devs[bmi.MountPoint] = bmi.DeviceName
+	}
+	assert.Equal(t, map[string]string{
+		"/":               "sda",
+		"/sysroot":        "sda",
+		"/var":            "sda",
+		"/etc":            "sda",
+		"/boot":           "sda",
+		"/var/lib/data":   "sdb",
+		"/var/lib/images": "sdb",
+		"/var/lib/crypt":  "dm-0",
+	}, devs)
+	assert.Equal(t, len(devs), len(bmis))
+	assert.Equal(t, "/ostree/deploy/rhcos/var", bmis[2].Root)
+	assert.Equal(t, uint32(0), bmis[5].Major)
+
+	major, minor, err := sysfs.BlockDeviceNumberOf("/dev/mapper/luks-data")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{253, 0}, []uint32{major, minor})
+	_, _, err = sysfs.BlockDeviceNumberOf("/dev/mapper/nonexistent")
+	assert.Error(t, err)
+}
@@ -2,9 +2,13 @@
 package devmon
 
 import (
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 )
@@ -13,13 +17,26 @@ var (
 	collectorsNamespace = "hpessa"
 )
 
+// register registers node info collector and all enabled collectors; the
+// latter are optionally labeled with node's name, so that their series are
+// identifiable without Prometheus relabeling (e.g. when federated).
 func (dex *deviceExporter) register() error {
-	return dex.registerCollectors(dex.listCollectors())
+	if err := dex.registerCollectors(dex.reg,
+		[]prometheus.Collector{dex.newNodeInfoCollector()}); err != nil {
+		return err
+	}
+	var reg prometheus.Registerer = dex.reg
+	if dex.opts.NodenameLabel {
+		reg = prometheus.WrapRegistererWith(
+			prometheus.Labels{"nodename": dex.sdp.ident.Nodename}, reg)
+	}
+	return dex.registerCollectors(reg, dex.listCollectors())
 }
 
-func (dex *deviceExporter) registerCollectors(cols []prometheus.Collector) error {
+func (dex *deviceExporter) registerCollectors(reg prometheus.Registerer,
+	cols []prometheus.Collector) error {
 	for _, c := range cols {
-		if err := dex.reg.Register(c); err != nil {
+		if err := reg.Register(c); err != nil {
 			dex.log.Error(err, "failed to register collector")
 			return err
 		}
@@ -27,44 +44,155 @@ func (dex *deviceExporter) registerCollectors(cols []prometheus.Collector) error
 	return nil
 }
 
+// collectorEntry is a named collector, with its capability of reporting on
+// local host and constructor
+type collectorEntry struct {
+	name    string
+	capable bool
+	create  func() deUpdater
+}
+
+func (dex *deviceExporter) collectorEntries() []collectorEntry {
+	hasRaid := dex.sdp.hasRaidBackends()
+	return []collectorEntry{
+		{"process", true, func() deUpdater {
+			return &plainUpdater{collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})}
+		}},
+		{"go", true, func() deUpdater {
+			return &plainUpdater{collectors.NewGoCollector()}
+		}},
+		{"blkdev", true, dex.newBlkdevCollector},
+		{"blkdev_io", true, dex.newBlkdevIOCollector},
+		{"blkdev_mount", true, dex.newBlkdevMountCollector},
+		{"nvme", true, dex.newNvmeControllersCollector},
+		{"loadavg", true, dex.newLoadAvgCollector},
+		{"pressure", true, dex.newPressureIOCollector},
+		{"raid_backend", true, dex.newRaidBackendsCollector},
+		{"raid_controller", hasRaid, dex.newRaidControllersCollector},
+		{"raid_logical", hasRaid, dex.newRaidLogicalDrivesCollector},
+		{"raid_physical", hasRaid, dex.newRaidPhysicalDrivesCollector},
+		{"smartctl", dex.sdp.hasRaidBackend("ssacli"), dex.newSsaSmartCollector},
+		{"pv", dex.sdp.hasKube(), dex.newPVBackingDeviceCollector},
+	}
+}
+
+// listCollectors returns the enabled collectors, of those which are capable
+// of reporting on local host. Each of the collectors is wrapped to report its
+// scrape duration and success.
 func (dex *deviceExporter) listCollectors() []prometheus.Collector {
-	cols := []prometheus.Collector{}
-	if dex.any {
-		prc := collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})
-		goc := collectors.NewGoCollector()
-		cols = append(cols, prc, goc)
-	}
-	if !dex.sdp.hasSSA {
-		cols = append(cols, dex.newBlkdevCollector())
-		cols = append(cols, dex.newBlkdevIOCollector())
-	}
-	cols = append(cols, dex.newExporterVersionCollector())
-	cols = append(cols, dex.newSsaVersionCollector())
-	cols = append(cols, dex.newSsaLogicalDrivesCollector())
-	cols = append(cols, dex.newSsaPhysicalDrivesCollector())
+	cols := []prometheus.Collector{dex.newExporterVersionCollector()}
+	for _, ent := range dex.collectorEntries() {
+		if ent.capable && dex.opts.Collectors[ent.name] {
+			cols = append(cols, dex.newScrapeCollector(ent.name, ent.create()))
+		}
+	}
 	return cols
 }
 
+// MetricNames returns the sorted names of the exporter's own metrics, as
+// described by its collectors (regardless of their capability on local host).
+func MetricNames() []string {
+	dex := &deviceExporter{
+		log:  logr.Discard(),
+		sdp:  &storageDevicesProbe{ident: &Ident{}},
+		opts: NewOptions(),
+	}
+	lists := [][]string{
+		dex.newNodeInfoCollector().metricNames(),
+		dex.newExporterVersionCollector().metricNames(),
+	}
+	for _, ent := range dex.collectorEntries() {
+		lists = append(lists, dex.newScrapeCollector(ent.name, ent.create()).metricNames())
+	}
+	names := []string{}
+	for _, list := range lists {
+		for _, name := range list {
+			if !containsString(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func collectorName(subsystem, name string) string {
 	return prometheus.BuildFQName(collectorsNamespace, subsystem, name)
 }
 
-func (dex *deviceExporter) newExporterVersionCollector() prometheus.Collector {
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: collectorName("exporter", "info"),
-		Help: "Version of the exporter.",
-		ConstLabels: map[string]string{
-			"version": Version(),
-		},
-	})
-	gauge.Set(1)
-	return gauge
+// infoCollector reports a constant info metric, which carries its values as
+// constant labels.
+type infoCollector struct {
+	deCollector
+}
+
+func (col *infoCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = col.update(ch)
+}
+
+func (col *infoCollector) update(ch chan<- prometheus.Metric) error {
+	ch <- prometheus.MustNewConstMetric(col.dsc[0], prometheus.GaugeValue, 1)
+	return nil
+}
+
+// newNodeInfoCollector returns node's identity, as known to the exporter
+func (dex *deviceExporter) newNodeInfoCollector() *infoCollector {
+	ident := dex.sdp.ident
+	uname, err := Uname()
+	if err != nil {
+		dex.log.Error(err, "failed to uname")
+	}
+	col := &infoCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			"node", "info",
+			"Identity of the node.", nil, prometheus.Labels{
+				"nodename": ident.Nodename,
+				"hostip":   ident.HostIP,
+				"kernel":   uname.Release,
+				"machine":  uname.Machine,
+				"pod":      ident.Name,
+			}),
+	}
+	return col
+}
+
+func (dex *deviceExporter) newExporterVersionCollector() *infoCollector {
+	col := &infoCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			"exporter", "info",
+			"Version of the exporter.", nil, prometheus.Labels{
+				"version": Version(),
+			}),
+	}
+	return col
+}
+
+// deUpdater is a collector which reports the failure of its underlying
+// probe, instead of swallowing it.
+type deUpdater interface {
+	Describe(ch chan<- *prometheus.Desc)
+	update(ch chan<- prometheus.Metric) error
+	metricNames() []string
 }
 
 type deCollector struct {
 	// nolint:structcheck
-	dex *deviceExporter
-	dsc []*prometheus.Desc
+	dex   *deviceExporter
+	dsc   []*prometheus.Desc
+	names []string
+}
+
+// newDesc returns the descriptor of one of the collector's metrics, and
+// records its fully-qualified name (which prometheus.Desc does not expose).
+func (col *deCollector) newDesc(subsystem, name, help string,
+	variableLabels []string, constLabels prometheus.Labels) *prometheus.Desc {
+	fqName := collectorName(subsystem, name)
+	col.names = append(col.names, fqName)
+	return prometheus.NewDesc(fqName, help, variableLabels, constLabels)
 }
 
 func (col *deCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -73,27 +201,186 @@ func (col *deCollector) Describe(ch chan<- *prometheus.Desc) {
 	}
 }
 
-type ssaVersionCollector struct {
+func (col *deCollector) metricNames() []string {
+	return col.names
+}
+
+// plainUpdater adapts a prometheus collector, which never fails, to
+// deUpdater interface. Its metrics are not the exporter's own, and hence have
+// no names to expose.
+type plainUpdater struct {
+	prometheus.Collector
+}
+
+func (pu *plainUpdater) update(ch chan<- prometheus.Metric) error {
+	pu.Collect(ch)
+	return nil
+}
+
+func (pu *plainUpdater) metricNames() []string {
+	return nil
+}
+
+// scrapeCollector wraps a named collector to report its scrape duration and
+// success. The underlying error is logged once per failure transition, rather
+// than on each scrape.
+type scrapeCollector struct {
 	deCollector
+	name    string
+	upd     deUpdater
+	mtx     sync.Mutex
+	failing bool
 }
 
-func (col *ssaVersionCollector) Collect(ch chan<- prometheus.Metric) {
-	vers, err := RunSsaVersion()
+func (dex *deviceExporter) newScrapeCollector(name string, upd deUpdater) *scrapeCollector {
+	subsys := "scrape_collector"
+	labels := map[string]string{"collector": name}
+	col := &scrapeCollector{name: name, upd: upd}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			subsys, "duration_seconds",
+			"Duration of a collector scrape.", nil, labels),
+
+		col.newDesc(
+			subsys, "success",
+			"Whether a collector succeeded.", nil, labels),
+	}
+	return col
+}
+
+func (col *scrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+	col.deCollector.Describe(ch)
+	col.upd.Describe(ch)
+}
+
+func (col *scrapeCollector) metricNames() []string {
+	return append(append([]string{}, col.names...), col.upd.metricNames()...)
+}
+
+func (col *scrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	begin := time.Now()
+	err := col.upd.update(ch)
+	duration := time.Since(begin).Seconds()
+	success := 1.0
 	if err != nil {
-		vers = "N/A"
+		success = 0
+	}
+	col.mtx.Lock()
+	if err != nil && !col.failing {
+		col.dex.log.Error(err, "collector failed", "collector", col.name)
+	} else if err == nil && col.failing {
+		col.dex.log.Info("collector recovered", "collector", col.name)
 	}
+	col.failing = err != nil
+	col.mtx.Unlock()
+
 	ch <- prometheus.MustNewConstMetric(col.dsc[0],
-		prometheus.GaugeValue, 1, vers)
+		prometheus.GaugeValue, duration)
+	ch <- prometheus.MustNewConstMetric(col.dsc[1],
+		prometheus.GaugeValue, success)
+}
+
+type raidBackendsCollector struct {
+	deCollector
+}
+
+// update reports each of the known backends as up when it was detected on
+// local host and its latest probe (shared with the RAID collectors)
+// succeeded. The version is the one reported at detection.
+func (col *raidBackendsCollector) update(ch chan<- prometheus.Metric) error {
+	errs := col.dex.sdp.probeRaidBackendErrors()
+	for _, rbe := range col.dex.sdp.rball {
+		up := 0.0
+		if col.dex.sdp.hasRaidBackend(rbe.Name()) {
+			ch <- prometheus.MustNewConstMetric(col.dsc[0],
+				prometheus.GaugeValue, 1, rbe.Name(), rbe.Vendor(),
+				col.dex.sdp.rbvers[rbe.Name()])
+			if errs[rbe.Name()] == nil {
+				up = 1
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(col.dsc[1],
+			prometheus.GaugeValue, up, rbe.Name())
+	}
+	return nil
 }
 
-func (dex *deviceExporter) newSsaVersionCollector() prometheus.Collector {
-	col := &ssaVersionCollector{}
+func (dex *deviceExporter) newRaidBackendsCollector() deUpdater {
+	col := &raidBackendsCollector{}
 	col.dex = dex
 	col.dsc = []*prometheus.Desc{
-		prometheus.NewDesc(
-			collectorName("ssacli", "info"),
-			"Version of local SSA cli utility.",
-			[]string{"version"}, nil),
+		col.newDesc(
+			"raid_backend", "info",
+			"Version of local RAID management utility.",
+			[]string{"backend", "vendor", "version"}, nil),
+		col.newDesc(
+			"backend", "up",
+			"Whether RAID backend is usable on local host (1) or not (0).",
+			[]string{"backend"}, nil),
+	}
+	return col
+}
+
+type raidControllersCollector struct {
+	deCollector
+}
+
+func (col *raidControllersCollector) update(ch chan<- prometheus.Metric) error {
+	ctrls, err := col.dex.sdp.probeRaidControllers()
+	if err != nil {
+		return err
+	}
+	for _, ctrl := range ctrls {
+		ch <- prometheus.MustNewConstMetric(col.dsc[0],
+			prometheus.GaugeValue, 1,
+			ctrl.Vendor, ctrl.ID, ctrl.Model, ctrl.Serial, ctrl.Firmware)
+
+		statuses := []string{ctrl.Status, ctrl.CacheStatus, ctrl.BatteryStatus}
+		for i, status := range statuses {
+			if status == "" {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(col.dsc[i+1],
+				prometheus.GaugeValue, statusToValue(status),
+				ctrl.Vendor, ctrl.ID, status)
+		}
+		if ctrl.Temperature >= 0 {
+			ch <- prometheus.MustNewConstMetric(col.dsc[4],
+				prometheus.GaugeValue, float64(ctrl.Temperature),
+				ctrl.Vendor, ctrl.ID)
+		}
+	}
+	return nil
+}
+
+func (dex *deviceExporter) newRaidControllersCollector() deUpdater {
+	subsys := "raid_controller"
+	labels := []string{"vendor", "controller", "status"}
+	col := &raidControllersCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			subsys, "info",
+			"Information of RAID controller",
+			[]string{"vendor", "controller", "model", "serial", "firmware"}, nil),
+
+		col.newDesc(
+			subsys, "status",
+			"Status of RAID controller", labels, nil),
+
+		col.newDesc(
+			subsys, "cache_status",
+			"Status of RAID controller cache", labels, nil),
+
+		col.newDesc(
+			subsys, "battery_status",
+			"Status of RAID controller cache battery/capacitor", labels, nil),
+
+		col.newDesc(
+			subsys, "temperature",
+			"Current temperature of RAID controller",
+			[]string{"vendor", "controller"}, nil),
 	}
 	return col
 }
@@ -102,8 +389,11 @@ type blkdevCollector struct {
 	deCollector
 }
 
-func (col *blkdevCollector) Collect(ch chan<- prometheus.Metric) {
-	bdis, _ := col.dex.sdp.probeBlockDevices()
+func (col *blkdevCollector) update(ch chan<- prometheus.Metric) error {
+	bdis, err := col.dex.sdp.probeBlockDevices()
+	if err != nil {
+		return err
+	}
 	for _, bdi := range bdis {
 		ch <- prometheus.MustNewConstMetric(col.dsc[0],
 			prometheus.GaugeValue,
@@ -113,27 +403,60 @@ func (col *blkdevCollector) Collect(ch chan<- prometheus.Metric) {
 			strconv.Itoa(int(bdi.Minor)),
 			bdi.Vendor,
 			bdi.Model)
+		labels := []string{bdi.Name, bdi.WWN, bdi.Serial}
+		for _, prop := range col.dex.opts.UdevProperties {
+			labels = append(labels, bdi.Udev[prop])
+		}
+		ch <- prometheus.MustNewConstMetric(col.dsc[1],
+			prometheus.GaugeValue, 1, labels...)
 	}
+	return nil
 }
 
-func (dex *deviceExporter) newBlkdevCollector() prometheus.Collector {
+func (dex *deviceExporter) newBlkdevCollector() deUpdater {
+	infoLabels := append([]string{}, blkdevInfoLabels...)
+	for _, prop := range dex.opts.UdevProperties {
+		infoLabels = append(infoLabels, udevPropertyLabel(prop))
+	}
 	col := &blkdevCollector{}
 	col.dex = dex
 	col.dsc = []*prometheus.Desc{
-		prometheus.NewDesc(
-			collectorName("blkdev", "size_bytes"),
+		col.newDesc(
+			"blkdev", "size_bytes",
 			"Block device size in bytes.",
 			[]string{"name", "major", "minor", "vendor", "model"}, nil),
+		col.newDesc(
+			"blkdev", "info",
+			"Persistent identifiers of block device.",
+			infoLabels, nil),
 	}
 	return col
 }
 
+// udevPropertyLabel converts udev property name into valid metric label name
+// (e.g., "ID_FS_TYPE" -> "id_fs_type")
+func udevPropertyLabel(prop string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, prop)
+}
+
 type blkdevIOCollector struct {
 	deCollector
 }
 
-func (col *blkdevIOCollector) Collect(ch chan<- prometheus.Metric) {
-	bdis, _ := col.dex.sdp.probeBlockDevicesIO()
+func (col *blkdevIOCollector) update(ch chan<- prometheus.Metric) error {
+	bdis, err := col.dex.sdp.probeBlockDevicesIO()
+	if err != nil {
+		return err
+	}
 	for _, bdi := range bdis {
 		ch <- prometheus.MustNewConstMetric(col.dsc[0],
 			prometheus.GaugeValue,
@@ -144,68 +467,297 @@ func (col *blkdevIOCollector) Collect(ch chan<- prometheus.Metric) {
 			float64(bdi.WritesIOs),
 			bdi.DeviceName)
 	}
+	return nil
 }
 
-func (dex *deviceExporter) newBlkdevIOCollector() prometheus.Collector {
+func (dex *deviceExporter) newBlkdevIOCollector() deUpdater {
 	col := &blkdevIOCollector{}
 	col.dex = dex
 	col.dsc = []*prometheus.Desc{
-		prometheus.NewDesc(
-			collectorName("blkdev", "read_ios"),
+		col.newDesc(
+			"blkdev", "read_ios",
 			"Read I/O count.", []string{"name"}, nil),
-		prometheus.NewDesc(
-			collectorName("blkdev", "write_ios"),
+		col.newDesc(
+			"blkdev", "write_ios",
 			"Write I/O count.", []string{"name"}, nil),
 	}
 	return col
 }
 
-type ssaLogicalDrivesCollector struct {
+type blkdevMountCollector struct {
+	deCollector
+}
+
+func (col *blkdevMountCollector) update(ch chan<- prometheus.Metric) error {
+	bmis, err := col.dex.sdp.probeBlockDevicesMounts()
+	if err != nil {
+		return err
+	}
+	for _, bmi := range bmis {
+		labels := []string{bmi.DeviceName, bmi.MountPoint, bmi.FSType}
+
+		ch <- prometheus.MustNewConstMetric(col.dsc[0],
+			prometheus.GaugeValue, 1, labels...)
+
+		ch <- prometheus.MustNewConstMetric(col.dsc[1],
+			prometheus.GaugeValue, float64(bmi.SizeBytes), labels...)
+
+		ch <- prometheus.MustNewConstMetric(col.dsc[2],
+			prometheus.GaugeValue, float64(bmi.FreeBytes), labels...)
+
+		ch <- prometheus.MustNewConstMetric(col.dsc[3],
+			prometheus.GaugeValue, float64(bmi.AvailBytes), labels...)
+	}
+	return nil
+}
+
+func (dex *deviceExporter) newBlkdevMountCollector() deUpdater {
+	subsys := "blkdev_mount"
+	labels := []string{"device", "mountpoint", "fstype"}
+	col := &blkdevMountCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			subsys, "info",
+			"File-system mounted on block device", labels, nil),
+
+		col.newDesc(
+			subsys, "size_bytes",
+			"File-system size in bytes", labels, nil),
+
+		col.newDesc(
+			subsys, "free_bytes",
+			"File-system free space in bytes", labels, nil),
+
+		col.newDesc(
+			subsys, "avail_bytes",
+			"File-system space available to non-root users in bytes", labels, nil),
+	}
+	return col
+}
+
+type nvmeControllersCollector struct {
+	deCollector
+}
+
+func (col *nvmeControllersCollector) update(ch chan<- prometheus.Metric) error {
+	ncis, err := col.dex.sdp.probeNvmeControllers()
+	if err != nil {
+		return err
+	}
+	for _, nci := range ncis {
+		ch <- prometheus.MustNewConstMetric(col.dsc[0],
+			prometheus.GaugeValue, 1,
+			nci.Name,
+			nci.Model,
+			nci.Serial,
+			nci.FirmwareRev,
+			nci.Transport,
+			strconv.Itoa(int(nci.Cntlid)))
+
+		ch <- prometheus.MustNewConstMetric(col.dsc[1],
+			prometheus.GaugeValue, nvmeStateToValue(nci.State),
+			nci.Name, nci.State)
+
+		for _, ns := range nci.Namespaces {
+			ch <- prometheus.MustNewConstMetric(col.dsc[2],
+				prometheus.GaugeValue, 1, nci.Name, ns)
+		}
+	}
+	return nil
+}
+
+func (dex *deviceExporter) newNvmeControllersCollector() deUpdater {
+	subsys := "nvme_controller"
+	col := &nvmeControllersCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			subsys, "info",
+			"Information of NVMe controller",
+			[]string{"controller", "model", "serial", "firmware", "transport", "cntlid"},
+			nil),
+
+		col.newDesc(
+			subsys, "state",
+			"State of NVMe controller (0 when live)",
+			[]string{"controller", "state"}, nil),
+
+		col.newDesc(
+			"nvme_namespace", "info",
+			"Block device of NVMe namespace",
+			[]string{"controller", "name"}, nil),
+	}
+	return col
+}
+
+type loadAvgCollector struct {
+	deCollector
+}
+
+func (col *loadAvgCollector) update(ch chan<- prometheus.Metric) error {
+	lavg, err := col.dex.sdp.probeLoadAvg()
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(col.dsc[0],
+		prometheus.GaugeValue, lavg.Load1)
+	ch <- prometheus.MustNewConstMetric(col.dsc[1],
+		prometheus.GaugeValue, lavg.Load5)
+	ch <- prometheus.MustNewConstMetric(col.dsc[2],
+		prometheus.GaugeValue, lavg.Load15)
+	return nil
+}
+
+func (dex *deviceExporter) newLoadAvgCollector() deUpdater {
+	col := &loadAvgCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			"node", "load1",
+			"1m load average.", nil, nil),
+		col.newDesc(
+			"node", "load5",
+			"5m load average.", nil, nil),
+		col.newDesc(
+			"node", "load15",
+			"15m load average.", nil, nil),
+	}
+	return col
+}
+
+type pressureIOCollector struct {
 	deCollector
 }
 
-func (col *ssaLogicalDrivesCollector) Collect(ch chan<- prometheus.Metric) {
-	sdis, _ := col.dex.sdp.probeDevices()
+func (col *pressureIOCollector) update(ch chan<- prometheus.Metric) error {
+	psi, err := col.dex.sdp.probePressureIO()
+	if err != nil {
+		return err
+	}
+	if psi != nil {
+		col.collectPressure(ch, col.dsc[0:4], psi)
+	}
+	cgpsi, err := col.dex.sdp.probeCgroupsPressureIO()
+	if err != nil {
+		return err
+	}
+	for cgroup, psi := range cgpsi {
+		col.collectPressure(ch, col.dsc[4:8], psi, cgroup)
+	}
+	return nil
+}
+
+func (col *pressureIOCollector) collectPressure(ch chan<- prometheus.Metric,
+	dsc []*prometheus.Desc, psi *SysPressure, labels ...string) {
+	kinds := map[string]*SysPressureStat{
+		"some": &psi.Some,
+		"full": psi.Full,
+	}
+	for kind, stat := range kinds {
+		if stat == nil {
+			continue
+		}
+		lvs := append(append([]string{}, labels...), kind)
+
+		ch <- prometheus.MustNewConstMetric(dsc[0],
+			prometheus.GaugeValue, stat.Avg10, lvs...)
+
+		ch <- prometheus.MustNewConstMetric(dsc[1],
+			prometheus.GaugeValue, stat.Avg60, lvs...)
+
+		ch <- prometheus.MustNewConstMetric(dsc[2],
+			prometheus.GaugeValue, stat.Avg300, lvs...)
+
+		ch <- prometheus.MustNewConstMetric(dsc[3],
+			prometheus.CounterValue, float64(stat.TotalUSec)/1e6, lvs...)
+	}
+}
+
+func (dex *deviceExporter) newPressureIOCollector() deUpdater {
+	col := &pressureIOCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{}
+	subsyss := []string{"pressure_io", "cgroup_pressure_io"}
+	labelss := [][]string{{"kind"}, {"cgroup", "kind"}}
+	for i, subsys := range subsyss {
+		labels := labelss[i]
+		col.dsc = append(col.dsc,
+			col.newDesc(
+				subsys, "avg10",
+				"I/O stall percentage over last 10 seconds", labels, nil),
+
+			col.newDesc(
+				subsys, "avg60",
+				"I/O stall percentage over last 60 seconds", labels, nil),
+
+			col.newDesc(
+				subsys, "avg300",
+				"I/O stall percentage over last 300 seconds", labels, nil),
+
+			col.newDesc(
+				subsys, "stalled_seconds_total",
+				"Total time in seconds tasks were stalled on I/O", labels, nil),
+		)
+	}
+	return col
+}
+
+type raidLogicalDrivesCollector struct {
+	deCollector
+}
+
+func (col *raidLogicalDrivesCollector) update(ch chan<- prometheus.Metric) error {
+	sdis, err := col.dex.sdp.probeDevices()
+	if err != nil {
+		return err
+	}
 	for _, sdi := range sdis {
-		ldi := sdi.SsaLogicalDrive
+		ldi := sdi.LogicalDrive
 		if ldi == nil {
 			continue
 		}
 		ch <- prometheus.MustNewConstMetric(col.dsc[0],
 			prometheus.GaugeValue,
 			statusToValue(ldi.Status),
-			ldi.ArrayName, ldi.DiskName, ldi.Status)
+			sdi.Controller.Vendor, ldi.ArrayName, ldi.DiskName, ldi.Status)
 	}
+	return nil
 }
 
-func (dex *deviceExporter) newSsaLogicalDrivesCollector() prometheus.Collector {
-	col := &ssaLogicalDrivesCollector{}
+func (dex *deviceExporter) newRaidLogicalDrivesCollector() deUpdater {
+	col := &raidLogicalDrivesCollector{}
 	col.dex = dex
 	col.dsc = []*prometheus.Desc{
-		prometheus.NewDesc(
-			collectorName("ssa_logical_device", "status"),
+		col.newDesc(
+			"raid_logical_device", "status",
 			"Status of logical device",
-			[]string{"arrayname", "diskname", "status"}, nil),
+			[]string{"vendor", "arrayname", "diskname", "status"}, nil),
 	}
 	return col
 }
 
-type ssaPhysicalDrivesCollector struct {
+type raidPhysicalDrivesCollector struct {
 	deCollector
 }
 
-func (col *ssaPhysicalDrivesCollector) Collect(ch chan<- prometheus.Metric) {
-	sdis, _ := col.dex.sdp.probeDevices()
+func (col *raidPhysicalDrivesCollector) update(ch chan<- prometheus.Metric) error {
+	sdis, err := col.dex.sdp.probeDevices()
+	if err != nil {
+		return err
+	}
 	for _, sdi := range sdis {
-		ldi := sdi.SsaLogicalDrive
+		ldi := sdi.LogicalDrive
 		if ldi == nil {
 			continue
 		}
 		for _, pdi := range ldi.PhysicalDrives {
-			labels := []string{ldi.DiskName, pdi.ID, pdi.Box, pdi.Bay, pdi.UniqueID}
+			labels := []string{sdi.Controller.Vendor,
+				ldi.DiskName, pdi.ID, pdi.Box, pdi.Bay, pdi.UniqueID}
 
 			ch <- prometheus.MustNewConstMetric(col.dsc[0],
-				prometheus.GaugeValue, statusToValue(pdi.Status), labels...)
+				prometheus.GaugeValue, statusToValue(pdi.Status),
+				append(labels, pdi.Status)...)
 
 			ch <- prometheus.MustNewConstMetric(col.dsc[1],
 				prometheus.GaugeValue, float64(pdi.SizeBytes), labels...)
@@ -218,35 +770,172 @@ func (col *ssaPhysicalDrivesCollector) Collect(ch chan<- prometheus.Metric) {
 
 			ch <- prometheus.MustNewConstMetric(col.dsc[4],
 				prometheus.GaugeValue, float64(pdi.PowerHours), labels...)
+
+			if pdi.UsageRemaining >= 0 {
+				ch <- prometheus.MustNewConstMetric(col.dsc[5],
+					prometheus.GaugeValue, pdi.UsageRemaining, labels...)
+			}
 		}
 	}
+	return nil
 }
 
-func (dex *deviceExporter) newSsaPhysicalDrivesCollector() prometheus.Collector {
-	subsys := "ssa_physical_device"
-	labels := []string{"dev", "id", "box", "bay", "uniqueid"}
-	col := &ssaPhysicalDrivesCollector{}
+func (dex *deviceExporter) newRaidPhysicalDrivesCollector() deUpdater {
+	subsys := "raid_physical_device"
+	labels := []string{"vendor", "dev", "id", "box", "bay", "uniqueid"}
+	col := &raidPhysicalDrivesCollector{}
 	col.dex = dex
 	col.dsc = []*prometheus.Desc{
-		prometheus.NewDesc(
-			collectorName(subsys, "status"),
-			"Status of physical device", labels, nil),
+		col.newDesc(
+			subsys, "status",
+			"Status of physical device",
+			append(labels, "status"), nil),
 
-		prometheus.NewDesc(
-			collectorName(subsys, "size"),
+		col.newDesc(
+			subsys, "size",
 			"Size in bytes of physical device", labels, nil),
 
-		prometheus.NewDesc(
-			collectorName(subsys, "temp_curr"),
+		col.newDesc(
+			subsys, "temp_curr",
 			"Current temperature of physical device", labels, nil),
 
-		prometheus.NewDesc(
-			collectorName(subsys, "temp_maxi"),
+		col.newDesc(
+			subsys, "temp_maxi",
 			"Maximal temperature of physical device", labels, nil),
 
-		prometheus.NewDesc(
-			collectorName(subsys, "power_hours"),
+		col.newDesc(
+			subsys, "power_hours",
 			"Power on in hours", labels, nil),
+
+		col.newDesc(
+			subsys, "usage_remaining",
+			"Remaining endurance percentage of SSD physical device", labels, nil),
+	}
+	return col
+}
+
+type pvBackingDeviceCollector struct {
+	deCollector
+}
+
+func (col *pvBackingDeviceCollector) update(ch chan<- prometheus.Metric) error {
+	pvis, err := col.dex.sdp.probePersistentVolumes()
+	if err != nil {
+		return err
+	}
+	for _, pvi := range pvis {
+		claim := ""
+		namespace := ""
+		if ref := pvi.PersistentVolume.Spec.ClaimRef; ref != nil {
+			claim = ref.Name
+			namespace = ref.Namespace
+		}
+		vendor := ""
+		controller := ""
+		logicaldrive := ""
+		if pvi.Controller != nil && pvi.LogicalDrive != nil {
+			vendor = pvi.Controller.Vendor
+			controller = pvi.Controller.ID
+			logicaldrive = pvi.LogicalDrive.ID
+		}
+		ch <- prometheus.MustNewConstMetric(col.dsc[0],
+			prometheus.GaugeValue, 1,
+			pvi.PersistentVolume.Name, claim, namespace, pvi.Device,
+			vendor, controller, logicaldrive)
+	}
+	return nil
+}
+
+func (dex *deviceExporter) newPVBackingDeviceCollector() deUpdater {
+	col := &pvBackingDeviceCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			"pv", "backing_device_info",
+			"Local persistent volume's backing block device and RAID logical drive",
+			[]string{"persistentvolume", "claim", "namespace", "device",
+				"vendor", "controller", "logicaldrive"}, nil),
+	}
+	return col
+}
+
+type ssaSmartCollector struct {
+	deCollector
+}
+
+func (col *ssaSmartCollector) update(ch chan<- prometheus.Metric) error {
+	ssis, err := col.dex.sdp.probeSsaSmart()
+	if err != nil {
+		return err
+	}
+	for _, ssi := range ssis {
+		ldi := ssi.LogicalDrive
+		pdi := ssi.PhysicalDrive
+		smart := ssi.Smart
+		labels := []string{ssi.Controller.Vendor,
+			ldi.DiskName, pdi.ID, pdi.Box, pdi.Bay, pdi.UniqueID}
+
+		if smart.Passed != nil {
+			passed := float64(0)
+			if *smart.Passed {
+				passed = 1
+			}
+			ch <- prometheus.MustNewConstMetric(col.dsc[0],
+				prometheus.GaugeValue, passed, labels...)
+		}
+
+		values := []int64{
+			smart.PowerOnHours,
+			smart.Temperature,
+			smart.ReallocatedSectors,
+			smart.PendingSectors,
+			smart.OfflineUncorrectable,
+			smart.CRCErrors,
+		}
+		for i, val := range values {
+			if val < 0 {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(col.dsc[i+1],
+				prometheus.GaugeValue, float64(val), labels...)
+		}
+	}
+	return nil
+}
+
+func (dex *deviceExporter) newSsaSmartCollector() deUpdater {
+	subsys := "raid_physical_device_smart"
+	labels := []string{"vendor", "dev", "id", "box", "bay", "uniqueid"}
+	col := &ssaSmartCollector{}
+	col.dex = dex
+	col.dsc = []*prometheus.Desc{
+		col.newDesc(
+			subsys, "passed",
+			"SMART overall-health self-assessment passed", labels, nil),
+
+		col.newDesc(
+			subsys, "power_on_hours",
+			"SMART power on in hours", labels, nil),
+
+		col.newDesc(
+			subsys, "temperature",
+			"SMART current temperature of physical device", labels, nil),
+
+		col.newDesc(
+			subsys, "reallocated_sectors",
+			"SMART reallocated sectors count (grown defects for SCSI)", labels, nil),
+
+		col.newDesc(
+			subsys, "pending_sectors",
+			"SMART current pending sectors count", labels, nil),
+
+		col.newDesc(
+			subsys, "offline_uncorrectable",
+			"SMART offline uncorrectable sectors count", labels, nil),
+
+		col.newDesc(
+			subsys, "crc_errors",
+			"SMART UDMA CRC errors count", labels, nil),
 	}
 	return col
 }
@@ -255,6 +944,13 @@ func statusToValue(status string) float64 {
 	return float64(statusToInt(status))
 }
 
+func nvmeStateToValue(state string) float64 {
+	if state == "live" {
+		return 0
+	}
+	return 1
+}
+
 func statusToInt(status string) int {
 	var ret int
 	if strings.ToUpper(status) != "OK" {
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type DevFS struct {
+	PseudoFS
+}
+
+const (
+	devDefaultMountPoint = "/dev"
+)
+
+func NewDevFS() *DevFS {
+	return NewDevFSAt(devDefaultMountPoint)
+}
+
+func NewDevFSAt(prefix string) *DevFS {
+	return &DevFS{
+		PseudoFS: PseudoFS{
+			Prefix: prefix,
+		},
+	}
+}
+
+// DiskLinks resolves the symbolic links under /dev/disk/<kind>/ (e.g. "by-id",
+// "by-path") into a map of device names to their (sorted) link names.
+func (devfs *DevFS) DiskLinks(kind string) (map[string][]string, error) {
+	ret := map[string][]string{}
+	links, err := devfs.ReadDir("disk", kind)
+	if err != nil {
+		return ret, err
+	}
+	for _, link := range links {
+		target, err := os.Readlink(link)
+		if err != nil {
+			continue
+		}
+		dev := filepath.Base(target)
+		ret[dev] = append(ret[dev], filepath.Base(link))
+	}
+	for _, names := range ret {
+		sort.Strings(names)
+	}
+	return ret, nil
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	eventsComponent = "hpessa-exporter"
+)
+
+// RaidStatusEvent represents a status transition of one of the RAID
+// components (controller, cache, battery, array, logical or physical drive).
+type RaidStatusEvent struct {
+	// Type is either Warning (upon degradation) or Normal (upon recovery)
+	Type string
+	// Reason is the component kind followed by transition, in CamelCase
+	// (e.g. PhysicalDriveFailed)
+	Reason  string
+	Message string
+}
+
+// raidStatusEntry is the status of a single RAID component, within a snapshot
+type raidStatusEntry struct {
+	kind   string
+	desc   string
+	status string
+}
+
+// raidStatusSnapshot flattens the status of all RAID components, keyed by
+// their unique (per node) identity.
+func raidStatusSnapshot(ctrls []RaidController) map[string]raidStatusEntry {
+	ret := map[string]raidStatusEntry{}
+	add := func(key, kind, desc, status string) {
+		if status != "" {
+			ret[key] = raidStatusEntry{kind: kind, desc: desc, status: status}
+		}
+	}
+	for _, ctrl := range ctrls {
+		ckey := ctrl.Vendor + "/" + ctrl.ID
+		cdesc := fmt.Sprintf("controller %s (%s)", ctrl.ID,
+			strings.TrimSpace(ctrl.Vendor+" "+ctrl.Model))
+		add(ckey, "Controller", cdesc, ctrl.Status)
+		add(ckey+"/cache", "ControllerCache", "cache of "+cdesc, ctrl.CacheStatus)
+		add(ckey+"/battery", "CacheBattery", "cache battery of "+cdesc, ctrl.BatteryStatus)
+		for _, arr := range ctrl.Arrays {
+			add(ckey+"/array/"+arr.Name, "Array",
+				fmt.Sprintf("array %s of controller %s", arr.Name, ctrl.ID), arr.Status)
+			for _, ld := range arr.LogicalDrives {
+				add(ckey+"/ld/"+ld.ID, "LogicalDrive",
+					fmt.Sprintf("logical drive %s (%s) of controller %s",
+						ld.ID, ld.DiskName, ctrl.ID), ld.Status)
+			}
+			for i := range arr.PhysicalDrives {
+				pd := &arr.PhysicalDrives[i]
+				add(ckey+"/pd/"+pd.ID, "PhysicalDrive",
+					raidPhysicalDriveDesc(pd, ctrl.ID), pd.Status)
+			}
+		}
+	}
+	return ret
+}
+
+// raidPhysicalDriveDesc returns human-readable description of physical drive,
+// e.g. 'physical drive 1I:1:2 (box 1, bay 2, serial S4EVNX0M901234) of
+// controller 0', without ssacli's 'physicaldrive' prefix of its ID.
+func raidPhysicalDriveDesc(pd *RaidPhysicalDrive, ctrlID string) string {
+	attrs := []string{}
+	if pd.Box != "" && pd.Bay != "" {
+		attrs = append(attrs, "box "+pd.Box, "bay "+pd.Bay)
+	}
+	if pd.Serial != "" {
+		attrs = append(attrs, "serial "+pd.Serial)
+	}
+	desc := "physical drive " + strings.TrimPrefix(pd.ID, "physicaldrive ")
+	if len(attrs) > 0 {
+		desc += " (" + strings.Join(attrs, ", ") + ")"
+	}
+	return desc + " of controller " + ctrlID
+}
+
+// raidStatusTransition returns the transition part of event reason for a
+// non-OK status
+func raidStatusTransition(status string) string {
+	switch status {
+	case "Failed", "Offline", "Missing":
+		return "Failed"
+	default:
+		return "Degraded"
+	}
+}
+
+// DiffRaidStatus returns the status transitions between two successive
+// snapshots of RAID controllers, in a stable order. Upon the first snapshot
+// (nil prev), only components with non-OK status are reported.
+func DiffRaidStatus(prev, curr []RaidController) []RaidStatusEvent {
+	ret := []RaidStatusEvent{}
+	psnap := raidStatusSnapshot(prev)
+	csnap := raidStatusSnapshot(curr)
+	keys := []string{}
+	for key := range csnap {
+		keys = append(keys, key)
+	}
+	for _, key := range sortRaidKeys(keys) {
+		cent := csnap[key]
+		pent, known := psnap[key]
+		if known && pent.status == cent.status {
+			continue
+		}
+		if cent.status != "OK" {
+			ret = append(ret, RaidStatusEvent{
+				Type:    corev1.EventTypeWarning,
+				Reason:  cent.kind + raidStatusTransition(cent.status),
+				Message: fmt.Sprintf("%s status: %s", cent.desc, cent.status),
+			})
+		} else if known {
+			ret = append(ret, RaidStatusEvent{
+				Type:   corev1.EventTypeNormal,
+				Reason: cent.kind + "Recovered",
+				Message: fmt.Sprintf("%s status: %s (was %s)",
+					cent.desc, cent.status, pent.status),
+			})
+		}
+	}
+	return ret
+}
+
+// raidEventRecorder emits Kubernetes Events on the Node object upon status
+// transitions between successive RAID probes of the node reporter's loop. Rate
+// limiting and deduplication of repeated events are carried out by the
+// broadcaster's event correlator.
+type raidEventRecorder struct {
+	log  logr.Logger
+	rec  record.EventRecorder
+	ref  *corev1.ObjectReference
+	last []RaidController
+}
+
+func newRaidEventRecorder(log logr.Logger, cset kubernetes.Interface,
+	nodename string) *raidEventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: cset.CoreV1().Events(""),
+	})
+	rec := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+		Component: eventsComponent,
+		Host:      nodename,
+	})
+	return &raidEventRecorder{
+		log: log,
+		rec: rec,
+		ref: &corev1.ObjectReference{
+			Kind: "Node",
+			Name: nodename,
+			UID:  types.UID(nodename),
+		},
+	}
+}
+
+// observe compares the current snapshot of RAID controllers with the previous
+// one, and emits an event for each status transition.
+func (rer *raidEventRecorder) observe(ctrls []RaidController) {
+	for _, ev := range DiffRaidStatus(rer.last, ctrls) {
+		rer.log.Info("RAID status transition", "reason", ev.Reason, "message", ev.Message)
+		rer.rec.Event(rer.ref, ev.Type, ev.Reason, ev.Message)
+	}
+	rer.last = ctrls
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffRaidStatus(t *testing.T) {
+	ok := []devmon.RaidController{newTestRaidController("OK", "OK", "OK")}
+	evs := devmon.DiffRaidStatus(nil, ok)
+	assert.Equal(t, 0, len(evs))
+	evs = devmon.DiffRaidStatus(ok, ok)
+	assert.Equal(t, 0, len(evs))
+
+	failed := []devmon.RaidController{
+		newTestRaidController("Degraded", "Interim Recovery Mode", "Failed"),
+	}
+	failed[0].BatteryStatus = "Failed"
+	evs = devmon.DiffRaidStatus(ok, failed)
+	reasons := []string{}
+	for _, ev := range evs {
+		assert.Equal(t, "Warning", ev.Type)
+		reasons = append(reasons, ev.Reason)
+	}
+	assert.Equal(t, []string{
+		"ArrayDegraded",
+		"CacheBatteryFailed",
+		"LogicalDriveDegraded",
+		"PhysicalDriveFailed",
+	}, reasons)
+	assert.Equal(t, "physical drive 1I:1:2 of controller 0 status: Failed",
+		evs[3].Message)
+	evs = devmon.DiffRaidStatus(nil, failed)
+	assert.Equal(t, 4, len(evs))
+
+	evs = devmon.DiffRaidStatus(failed, ok)
+	reasons = []string{}
+	for _, ev := range evs {
+		assert.Equal(t, "Normal", ev.Type)
+		reasons = append(reasons, ev.Reason)
+	}
+	assert.Equal(t, []string{
+		"ArrayRecovered",
+		"LogicalDriveRecovered",
+		"PhysicalDriveRecovered",
+	}, reasons)
+
+	located := []devmon.RaidController{newTestRaidController("OK", "OK", "Predictive Failure")}
+	pd := &located[0].Arrays[0].PhysicalDrives[1]
+	pd.Box = "1"
+	pd.Bay = "2"
+	pd.Serial = "S4EVNX0M901234"
+	evs = devmon.DiffRaidStatus(ok, located)
+	assert.Equal(t, 1, len(evs))
+	assert.Equal(t, "PhysicalDriveDegraded", evs[0].Reason)
+	assert.Equal(t, "physical drive 1I:1:2 (box 1, bay 2, serial S4EVNX0M901234) "+
+		"of controller 0 status: Predictive Failure", evs[0].Message)
+}
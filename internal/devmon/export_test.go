@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ExporterHealth exposes exporterHealth to tests, with injected clock
+type ExporterHealth struct {
+	*exporterHealth
+}
+
+func NewExporterHealth(interval time.Duration,
+	now func() time.Time) *ExporterHealth {
+	eh := newExporterHealth(interval)
+	eh.now = now
+	return &ExporterHealth{eh}
+}
+
+func (eh *ExporterHealth) SetInitialized(hasRaid bool) {
+	eh.setInitialized(hasRaid)
+}
+
+func (eh *ExporterHealth) Refresh(begin, end time.Time) {
+	eh.beginRefresh(begin)
+	if !end.IsZero() {
+		eh.endRefresh(end)
+	}
+}
+
+func (eh *ExporterHealth) ReadyError() error {
+	return eh.readyError(eh.now())
+}
+
+func (eh *ExporterHealth) ServeHealthz(w http.ResponseWriter, r *http.Request) {
+	eh.serveHealthz(w, r)
+}
+
+func (eh *ExporterHealth) ServeReadyz(w http.ResponseWriter, r *http.Request) {
+	eh.serveReadyz(w, r)
+}
+
+// ServeUntilTerminated serves handler on listener, as the exporter serves its
+// endpoints, until terminated via ctx; cancelProbes is the probes' cancel.
+func ServeUntilTerminated(ctx context.Context, cancelProbes context.CancelFunc,
+	listener net.Listener, handler http.Handler) error {
+	dex := &deviceExporter{
+		log:  logr.Discard(),
+		srv:  &http.Server{Handler: handler},
+		opts: &Options{},
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- dex.serveListener(listener, listener.Addr().String())
+	}()
+	return dex.run(ctx, cancelProbes, errc)
+}
+
+// PVSnapshot exposes pvSnapshot to tests
+type PVSnapshot struct {
+	pvSnapshot
+}
+
+func (pvs *PVSnapshot) Get(ctx context.Context, cset kubernetes.Interface,
+	nodename string, now time.Time) (*corev1.Node, []corev1.PersistentVolume, error) {
+	return pvs.get(ctx, cset, nodename, now)
+}
+
+// InventoryReporter exposes the inventory part of nodeReporter to tests
+type InventoryReporter struct {
+	nodeReporter
+}
+
+func NewInventoryReporter(cset kubernetes.Interface, dyn dynamic.Interface,
+	nodename string) *InventoryReporter {
+	return &InventoryReporter{nodeReporter{
+		log:       logr.Discard(),
+		cset:      cset,
+		dyn:       dyn,
+		nodename:  nodename,
+		inventory: true,
+	}}
+}
+
+func (ir *InventoryReporter) ApplyInventory(ctx context.Context, ctrls []RaidController) error {
+	return ir.applyInventory(ctx, ctrls)
+}
@@ -2,13 +2,21 @@
 package devmon
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/exporter-toolkit/web"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
@@ -16,23 +24,44 @@ var (
 	DefaultMetricsPort = int(8080)
 )
 
+const (
+	// HTTP server timeouts; write timeout allows for slow RAID tools, as
+	// metrics are collected upon scrape
+	httpReadTimeout  = 30 * time.Second
+	httpWriteTimeout = 2 * time.Minute
+	httpIdleTimeout  = 2 * time.Minute
+
+	// shutdownTimeout bounds the wait for in-flight requests upon termination,
+	// well within pod's default termination grace period
+	shutdownTimeout = 15 * time.Second
+)
+
 type deviceExporter struct {
 	log  logr.Logger
 	sdp  *storageDevicesProbe
 	reg  *prometheus.Registry
 	mux  *http.ServeMux
-	port int
-	any  bool
+	hlt  *exporterHealth
+	srv  *http.Server
+	opts *Options
 }
 
-func newDeviceExporter(log logr.Logger, port int) *deviceExporter {
+func newDeviceExporter(ctx context.Context, log logr.Logger,
+	opts *Options) *deviceExporter {
+	mux := http.NewServeMux()
 	return &deviceExporter{
-		log:  log,
-		sdp:  newStorageDevicesProbe(log),
-		reg:  prometheus.NewRegistry(),
-		mux:  http.NewServeMux(),
-		port: port,
-		any:  false,
+		log: log,
+		sdp: newStorageDevicesProbe(ctx, log, opts),
+		reg: prometheus.NewRegistry(),
+		mux: mux,
+		hlt: newExporterHealth(opts.ScrapeInterval),
+		srv: &http.Server{
+			Handler:      mux,
+			ReadTimeout:  httpReadTimeout,
+			WriteTimeout: httpWriteTimeout,
+			IdleTimeout:  httpIdleTimeout,
+		},
+		opts: opts,
 	}
 }
 
@@ -45,53 +74,193 @@ func (dex *deviceExporter) init() error {
 	if err := dex.register(); err != nil {
 		return err
 	}
+	dex.hlt.setInitialized(dex.sdp.hasRaidBackends())
+	dex.startNodeReporter()
 	return nil
 }
 
-func (dex *deviceExporter) serve() error {
-	addr := fmt.Sprintf(":%d", dex.port)
+// startNodeReporter starts publishing RAID health (and events) on local Node
+// in the background, when enabled and applicable.
+func (dex *deviceExporter) startNodeReporter() {
+	if !dex.opts.NodeLabels && !dex.opts.NodeCondition && !dex.opts.Inventory &&
+		!dex.opts.Events {
+		return
+	}
+	if !dex.sdp.hasKube() || !dex.sdp.hasRaidBackends() {
+		dex.log.Info("node reporter not applicable",
+			"kubernetes", dex.sdp.hasKube(), "raid", dex.sdp.hasRaidBackends())
+		return
+	}
+	nr := newNodeReporter(dex.log, dex.sdp)
+	go nr.run(dex.sdp.ctx)
+}
+
+// serve starts serving metrics and health endpoints in the background, before
+// initialization, so that readiness reflects its progress.
+func (dex *deviceExporter) serve() (<-chan error, error) {
+	addr := fmt.Sprintf(":%d", dex.opts.MetricsPort)
 	dex.log.Info("serve metrics", "addr", addr)
 
-	handler := promhttp.HandlerFor(dex.reg, promhttp.HandlerOpts{})
+	if dex.opts.WebConfigFile != "" {
+		if err := web.Validate(dex.opts.WebConfigFile); err != nil {
+			dex.log.Error(err, "invalid web config", "path", dex.opts.WebConfigFile)
+			return nil, err
+		}
+	}
+	var handler http.Handler = promhttp.HandlerFor(dex.reg, promhttp.HandlerOpts{})
+	handler = dex.hlt.instrumentRefresh(handler)
+	var invHandler http.Handler = http.HandlerFunc(dex.serveInventory)
+	if dex.opts.TokenReview {
+		kclnt, err := newClient()
+		if err != nil {
+			dex.log.Error(err, "failed to create clientset")
+			return nil, err
+		}
+		tr := newTokenReviewer(dex.log, kclnt.ClientSet)
+		handler = tr.wrap(handler)
+		invHandler = tr.wrap(invHandler)
+	}
 	dex.mux.Handle("/metrics", handler)
+	dex.mux.Handle(InventoryAPIPath, invHandler)
+	dex.mux.HandleFunc("/healthz", dex.hlt.serveHealthz)
+	dex.mux.HandleFunc("/readyz", dex.hlt.serveReadyz)
 
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		dex.log.Error(err, "failed to listen", "addr", addr)
-		return err
+		return nil, err
 	}
-	defer listener.Close()
+	errc := make(chan error, 1)
+	go func() {
+		defer listener.Close()
+		errc <- dex.serveListener(listener, addr)
+	}()
+	return errc, nil
+}
 
-	if err := http.Serve(listener, dex.mux); err != nil {
+func (dex *deviceExporter) serveListener(listener net.Listener, addr string) error {
+	err := web.Serve(listener, dex.srv, dex.opts.WebConfigFile, kitLogger{dex.log})
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		dex.log.Error(err, "HTTP server failure", "addr", addr)
 		return err
 	}
 	return nil
 }
 
-func RunDevicesExporter(port int) error {
-	log := zap.New(zap.UseFlagOptions(&zap.Options{}))
-	dex := newDeviceExporter(log, port)
-	if err := dex.init(); err != nil {
+// shutdown gracefully stops the HTTP server, waiting (up to a timeout) for
+// in-flight requests to complete.
+func (dex *deviceExporter) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := dex.srv.Shutdown(ctx); err != nil {
+		dex.log.Error(err, "HTTP server shutdown failure")
 		return err
 	}
-	if err := dex.serve(); err != nil {
+	return nil
+}
+
+// signalContext returns context which is canceled upon SIGINT or SIGTERM
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// run waits for termination (via ctx) or HTTP server failure. Upon
+// termination, it first shuts down the HTTP server gracefully, so that
+// in-flight scrapes complete, and only then cancels leftover probes (and
+// their child processes) via cancelProbes.
+func (dex *deviceExporter) run(ctx context.Context, cancelProbes context.CancelFunc,
+	errc <-chan error) error {
+	defer cancelProbes()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	dex.log.Info("received termination signal, shutting down")
+	err := dex.shutdown()
+	cancelProbes()
+	if err != nil {
+		return err
+	}
+	if err := <-errc; err != nil {
 		return err
 	}
+	dex.log.Info("devices exporter stopped")
 	return nil
 }
 
-func ProbePrintDevices() error {
+// RunDevicesExporter serves metrics until terminated by a signal, upon which
+// the HTTP server is shut down gracefully and then leftover probes (and their
+// child processes) are canceled.
+func RunDevicesExporter(opts *Options) error {
 	log := zap.New(zap.UseFlagOptions(&zap.Options{}))
-	sdp := newStorageDevicesProbe(log)
+	sigctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dex := newDeviceExporter(ctx, log, opts)
+	errc, err := dex.serve()
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-sigctx.Done()
+		// restore default handling of further signals, and cancel probes
+		// right away if still initializing, as no scrape depends on them
+		stop()
+		if !dex.hlt.isInitialized() {
+			cancel()
+		}
+	}()
+	if err := dex.init(); err != nil && sigctx.Err() == nil {
+		log.Error(err, "failed to init devices exporter")
+		_ = dex.shutdown()
+		return err
+	}
+	return dex.run(sigctx, cancel, errc)
+}
+
+func ProbePrintDevices(opts *Options) error {
+	log := zap.New(zap.UseFlagOptions(&zap.Options{}))
+	ctx, stop := signalContext()
+	defer stop()
+
+	sdp := newStorageDevicesProbe(ctx, log, opts)
 
 	if err := sdp.init(); err != nil {
 		return err
 	}
 	sdi, err := sdp.probeDevices()
 	if err != nil {
+		log.Error(err, "failed to probe devices")
 		return err
 	}
 	fmt.Printf("%+v\n", sdi)
 	return nil
 }
+
+// ProbePrintInventory probes local node's storage hardware once, and prints
+// its inventory as JSON (in the format of the inventory API).
+func ProbePrintInventory(opts *Options) error {
+	log := zap.New(zap.UseFlagOptions(&zap.Options{}))
+	ctx, stop := signalContext()
+	defer stop()
+
+	sdp := newStorageDevicesProbe(ctx, log, opts)
+
+	if err := sdp.init(); err != nil {
+		return err
+	}
+	inv, err := sdp.probeInventory()
+	if err != nil {
+		log.Error(err, "failed to probe inventory")
+		return err
+	}
+	dat, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(dat))
+	return nil
+}
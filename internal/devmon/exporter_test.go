@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeUntilTerminated(t *testing.T) {
+	probeCtx, cancelProbes := context.WithCancel(context.Background())
+	defer cancelProbes()
+	termCtx, terminate := context.WithCancel(context.Background())
+	defer terminate()
+
+	// a slow scrape, as with ssacli, which fails if its probe is canceled
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-time.After(500 * time.Millisecond):
+			_, _ = w.Write([]byte("ok"))
+		case <-probeCtx.Done():
+			http.Error(w, "canceled", http.StatusInternalServerError)
+		}
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	runc := make(chan error, 1)
+	go func() {
+		runc <- devmon.ServeUntilTerminated(termCtx, cancelProbes, listener, handler)
+	}()
+
+	type result struct {
+		code int
+		body string
+		err  error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+		if err != nil {
+			resc <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		dat, err := ioutil.ReadAll(resp.Body)
+		resc <- result{code: resp.StatusCode, body: string(dat), err: err}
+	}()
+
+	<-started
+	terminate()
+	res := <-resc
+	assert.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.code)
+	assert.Equal(t, "ok", res.body)
+	assert.NoError(t, <-runc)
+	assert.Error(t, probeCtx.Err())
+}
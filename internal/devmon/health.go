@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// staleMaxIntervals is the number of scrape intervals after which a
+	// refresh in-flight is considered wedged (not alive), and the last
+	// refresh is considered stale (not ready)
+	staleMaxIntervals = 3
+)
+
+// exporterHealth tracks the exporter's initialization and metrics refresh
+// (scrape) activity, for liveness and readiness endpoints.
+type exporterHealth struct {
+	mtx           sync.Mutex
+	interval      time.Duration
+	initialized   bool
+	hasRaid       bool
+	lastRefresh   time.Time
+	inflight      int
+	inflightSince time.Time
+	now           func() time.Time
+}
+
+func newExporterHealth(interval time.Duration) *exporterHealth {
+	return &exporterHealth{
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// setInitialized marks the completion of exporter's initialization, with
+// whether any of the RAID backends was detected on local host.
+func (eh *exporterHealth) setInitialized(hasRaid bool) {
+	eh.mtx.Lock()
+	defer eh.mtx.Unlock()
+	eh.initialized = true
+	eh.hasRaid = hasRaid
+}
+
+func (eh *exporterHealth) isInitialized() bool {
+	eh.mtx.Lock()
+	defer eh.mtx.Unlock()
+	return eh.initialized
+}
+
+func (eh *exporterHealth) beginRefresh(now time.Time) {
+	eh.mtx.Lock()
+	defer eh.mtx.Unlock()
+	if eh.inflight == 0 {
+		eh.inflightSince = now
+	}
+	eh.inflight++
+}
+
+func (eh *exporterHealth) endRefresh(now time.Time) {
+	eh.mtx.Lock()
+	defer eh.mtx.Unlock()
+	eh.inflight--
+	if eh.inflight == 0 {
+		eh.inflightSince = time.Time{}
+	}
+	eh.lastRefresh = now
+}
+
+// readyError returns non-nil error when the exporter is not ready: either it
+// did not complete its initial probe, no RAID backend was detected on local
+// host, or the last refresh is too old.
+func (eh *exporterHealth) readyError(now time.Time) error {
+	eh.mtx.Lock()
+	defer eh.mtx.Unlock()
+	if !eh.initialized {
+		return errors.New("not initialized")
+	}
+	if !eh.hasRaid {
+		return errors.New("no RAID backend detected")
+	}
+	maxAge := staleMaxIntervals * eh.interval
+	if !eh.lastRefresh.IsZero() && now.Sub(eh.lastRefresh) > maxAge {
+		return fmt.Errorf("last refresh at %s", eh.lastRefresh.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// liveError returns non-nil error when the exporter is wedged, with a refresh
+// in-flight for too long.
+func (eh *exporterHealth) liveError(now time.Time) error {
+	eh.mtx.Lock()
+	defer eh.mtx.Unlock()
+	maxAge := staleMaxIntervals * eh.interval
+	if eh.inflight > 0 && now.Sub(eh.inflightSince) > maxAge {
+		return fmt.Errorf("refresh in-flight since %s", eh.inflightSince.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// instrumentRefresh wraps metrics handler to track refresh activity
+func (eh *exporterHealth) instrumentRefresh(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eh.beginRefresh(eh.now())
+		defer func() { eh.endRefresh(eh.now()) }()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (eh *exporterHealth) serveHealthz(w http.ResponseWriter, _ *http.Request) {
+	if err := eh.liveError(eh.now()); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+func (eh *exporterHealth) serveReadyz(w http.ResponseWriter, _ *http.Request) {
+	if err := eh.readyError(eh.now()); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExporterHealthReady(t *testing.T) {
+	begin := time.Date(2022, 3, 14, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name        string
+		initialized bool
+		hasRaid     bool
+		refresh     time.Time
+		now         time.Time
+		ready       bool
+	}{
+		{"uninitialized", false, true, time.Time{}, begin, false},
+		{"no-raid", true, false, time.Time{}, begin, false},
+		{"no-refresh", true, true, time.Time{}, begin, true},
+		{"fresh", true, true, begin, begin.Add(time.Minute), true},
+		{"stale", true, true, begin, begin.Add(4 * time.Minute), false},
+	}
+	for _, tc := range cases {
+		now := tc.now
+		eh := devmon.NewExporterHealth(time.Minute,
+			func() time.Time { return now })
+		if tc.initialized {
+			eh.SetInitialized(tc.hasRaid)
+		}
+		if !tc.refresh.IsZero() {
+			eh.Refresh(tc.refresh, tc.refresh)
+		}
+		err := eh.ReadyError()
+		assert.Equal(t, tc.ready, err == nil, tc.name)
+
+		rec := httptest.NewRecorder()
+		eh.ServeReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if tc.ready {
+			assert.Equal(t, http.StatusOK, rec.Code, tc.name)
+		} else {
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code, tc.name)
+		}
+	}
+}
+
+func TestExporterHealthLive(t *testing.T) {
+	begin := time.Date(2022, 3, 14, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		begin time.Time
+		end   time.Time
+		now   time.Time
+		code  int
+	}{
+		{"no-refresh", time.Time{}, time.Time{}, begin, http.StatusOK},
+		{"fresh", begin, begin.Add(time.Second), begin.Add(time.Minute), http.StatusOK},
+		// stale refresh affects readiness only
+		{"stale", begin, begin.Add(time.Second), begin.Add(4 * time.Minute), http.StatusOK},
+		{"inflight", begin, time.Time{}, begin.Add(2 * time.Minute), http.StatusOK},
+		{"inflight-stuck", begin, time.Time{}, begin.Add(4 * time.Minute),
+			http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		now := tc.now
+		eh := devmon.NewExporterHealth(time.Minute,
+			func() time.Time { return now })
+		eh.SetInitialized(false)
+		if !tc.begin.IsZero() {
+			eh.Refresh(tc.begin, tc.end)
+		}
+		rec := httptest.NewRecorder()
+		eh.ServeHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, tc.code, rec.Code, tc.name)
+	}
+}
@@ -2,10 +2,8 @@
 package devmon
 
 import (
+	"context"
 	"errors"
-	"os"
-	"os/exec"
-	"path"
 	"strconv"
 	"strings"
 )
@@ -38,59 +36,17 @@ type SsaConfigInfo struct {
 	Slots []*SsaSlot
 }
 
-type SsaPhysicalDriveInfo struct {
-	ID         string `json:"id"`
-	Box        string `json:"box"`
-	Bay        string `json:"bay"`
-	Size       string `json:"size"`
-	SizeBytes  uint64 `json:"sizebytes"`
-	Status     string `json:"status"`
-	Serial     string `json:"serial"`
-	TempCurr   int64  `json:"tempcurr"`
-	TempMaxi   int64  `json:"tempmaxi"`
-	UniqueID   string `json:"uniqueid"`
-	PowerHours int64  `json:"powerhours"` // nolint:misspell
-}
-
-type SsaLogicalDriveInfo struct {
-	ID             string `json:"id"`
-	DiskName       string `json:"diskname"`
-	Size           string `json:"size"`
-	SizeBytes      uint64 `json:"sizebytes"`
-	Status         string `json:"status"`
-	UniqueID       string `json:"uniqueid"`
-	ArrayName      string `json:"arrayname"`
-	PhysicalDrives []SsaPhysicalDriveInfo
-}
-
-type SsaMap struct {
-	DevMap map[string]SsaLogicalDriveInfo
-}
-
 func LocateSsa() (string, error) {
 	knowns := []string{
 		"/usr/sbin/ssacli",
 		"/opt/smartstorageadmin/ssacli/bin/ssacli",
 		"/opt/hp/ssacli/bld/ssacli",
 	}
-	for _, loc := range knowns {
-		fi, err := os.Stat(loc)
-		if err != nil {
-			continue
-		}
-		mode := fi.Mode()
-		if !mode.IsRegular() {
-			continue
-		}
-		if (mode & 0111) > 0 {
-			return loc, nil
-		}
-	}
-	return "", errors.New("failed to locate ssacli")
+	return locateTool("ssacli", knowns)
 }
 
-func RunSsaVersion() (string, error) {
-	dat, err := executeSsaCommand("version")
+func RunSsaVersion(ctx context.Context) (string, error) {
+	dat, err := executeSsaCommand(ctx, "version")
 	if err != nil {
 		return "", err
 	}
@@ -110,20 +66,20 @@ func ParseSsaVersion(dat string) (string, error) {
 	return "", errors.New("failed to parse ssacli version")
 }
 
-func RunSsaShowConfig() (*SsaConfigInfo, error) {
-	out, err := executeSsaCommand("ctrl", "all", "show", "config", "detail")
+func RunSsaShowConfig(ctx context.Context) (*SsaConfigInfo, error) {
+	out, err := executeSsaCommand(ctx, "ctrl", "all", "show", "config", "detail")
 	if err != nil {
 		return nil, err
 	}
 	return ParseSsaShowConfig(out)
 }
 
-func executeSsaCommand(args ...string) (string, error) {
+func executeSsaCommand(ctx context.Context, args ...string) (string, error) {
 	loc, err := LocateSsa()
 	if err != nil {
 		return "", err
 	}
-	return executeCommand(loc, args...)
+	return executeCommand(ctx, loc, args...)
 }
 
 func ParseSsaShowConfig(dat string) (*SsaConfigInfo, error) {
@@ -270,45 +226,71 @@ func countLeading(str string, item rune) int {
 	return cnt
 }
 
-func ParseConfigToLogical(config *SsaConfigInfo) (*SsaMap, error) {
-	ret := NewSsaMap()
+// ParseSsaRaidControllers converts parsed ssacli config into vendor-neutral
+// representation of RAID controllers.
+func ParseSsaRaidControllers(config *SsaConfigInfo) []RaidController {
+	ret := []RaidController{}
 	for _, slot := range config.Slots {
+		ctrl := RaidController{}
+		ctrl.ID = slot.Values["Slot"]
+		ctrl.Model = strings.TrimSpace(strings.Split(slot.Title, " in Slot")[0])
+		ctrl.Serial = slot.Values["Serial Number"]
+		ctrl.Firmware = slot.Values["Firmware Version"]
+		ctrl.Status = slot.Values["Controller Status"]
+		ctrl.CacheStatus = slot.Values["Cache Status"]
+		ctrl.BatteryStatus = slot.Values["Battery/Capacitor Status"]
+		ctrl.Temperature = parseTempOr(slot.Values["Controller Temperature (C)"], -1)
 		for _, arr := range slot.Array {
-			if len(arr.LogicalDrive) != 1 {
-				continue
-			}
-			ld := arr.LogicalDrive[0]
-			name := path.Base(ld.Values["Disk Name"])
-			if name == "" {
-				continue
-			}
-			ldi := SsaLogicalDriveInfo{}
-			ldi.ID = valueOf(ld.Title)
-			ldi.DiskName = ld.Values["Disk Name"]
-			ldi.Size = ld.Values["Size"]
-			ldi.SizeBytes = parseSizeBytes(ldi.Size)
-			ldi.Status = ld.Values["Status"]
-			ldi.UniqueID = ld.Values["Unique Identifier"]
-			ldi.ArrayName = valueOf(arr.Title)
-			for _, pd := range arr.PhysicalDrive {
-				pdi := SsaPhysicalDriveInfo{}
-				pdi.ID = pd.Title
-				pdi.Box = pd.Values["Box"]
-				pdi.Bay = pd.Values["Bay"]
-				pdi.Size = pd.Values["Size"]
-				pdi.SizeBytes = parseSizeBytes(pdi.Size)
-				pdi.Status = pd.Values["Status"]
-				pdi.TempCurr = parseTemp(valueByPrefix(pd.Values, "Current Temperature"))
-				pdi.TempMaxi = parseTemp(valueByPrefix(pd.Values, "Maximum Temperature"))
-				pdi.UniqueID = pd.Values["Drive Unique ID"]
-				pdi.PowerHours = parseInt64(pd.Values["Power On Hours"])
-
-				ldi.PhysicalDrives = append(ldi.PhysicalDrives, pdi)
-			}
-			ret.DevMap[name] = ldi
+			ctrl.Arrays = append(ctrl.Arrays, ssaArrayToRaid(arr))
 		}
+		ret = append(ret, ctrl)
+	}
+	return ret
+}
+
+func ssaArrayToRaid(arr *SsaArray) RaidArray {
+	ra := RaidArray{}
+	ra.Name = valueOf(arr.Title)
+	ra.Status = arr.Values["Status"]
+	for _, pd := range arr.PhysicalDrive {
+		ra.PhysicalDrives = append(ra.PhysicalDrives, ssaPhysicalDriveToRaid(pd))
+	}
+	for _, ld := range arr.LogicalDrive {
+		rld := RaidLogicalDrive{}
+		rld.ID = valueOf(ld.Title)
+		rld.DiskName = ld.Values["Disk Name"]
+		rld.SizeBytes = parseSizeBytes(ld.Values["Size"])
+		rld.Status = ld.Values["Status"]
+		rld.UniqueID = ld.Values["Unique Identifier"]
+		rld.ArrayName = ra.Name
+		rld.RaidLevel = ld.Values["Fault Tolerance"]
+		rld.PhysicalDrives = append(rld.PhysicalDrives, ra.PhysicalDrives...)
+		ra.LogicalDrives = append(ra.LogicalDrives, rld)
 	}
-	return ret, nil
+	return ra
+}
+
+func ssaPhysicalDriveToRaid(pd *SsaPhysicalDrive) RaidPhysicalDrive {
+	rpd := RaidPhysicalDrive{}
+	rpd.ID = pd.Title
+	rpd.Box = pd.Values["Box"]
+	rpd.Bay = pd.Values["Bay"]
+	rpd.Model = strings.Join(strings.Fields(pd.Values["Model"]), " ")
+	rpd.Serial = pd.Values["Serial Number"]
+	rpd.Firmware = pd.Values["Firmware Revision"]
+	rpd.Interface = pd.Values["Interface Type"]
+	rpd.MediaType = "HDD"
+	if strings.HasPrefix(rpd.Interface, "Solid State") {
+		rpd.MediaType = "SSD"
+	}
+	rpd.SizeBytes = parseSizeBytes(pd.Values["Size"])
+	rpd.Status = pd.Values["Status"]
+	rpd.UniqueID = pd.Values["Drive Unique ID"]
+	rpd.TempCurr = parseTemp(valueByPrefix(pd.Values, "Current Temperature"))
+	rpd.TempMaxi = parseTemp(valueByPrefix(pd.Values, "Maximum Temperature"))
+	rpd.PowerHours = parseInt64(pd.Values["Power On Hours"])
+	rpd.UsageRemaining = parsePercent(pd.Values["Usage remaining"])
+	return rpd
 }
 
 func valueByPrefix(kv map[string]string, prefix string) string {
@@ -346,6 +328,8 @@ func parseSizeBytes(s string) uint64 {
 		switch strings.ToUpper(kv[1]) {
 		case "KB":
 			val *= float64(Kilo)
+		case "MB":
+			val *= float64(Mega)
 		case "GB":
 			val *= float64(Giga)
 		case "TB":
@@ -365,6 +349,22 @@ func parseTemp(s string) int64 {
 	return int64(val)
 }
 
+func parseTempOr(s string, dflt int64) int64 {
+	val, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return dflt
+	}
+	return int64(val)
+}
+
+func parsePercent(s string) float64 {
+	val, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return -1
+	}
+	return val
+}
+
 func parseInt64(s string) int64 {
 	val, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
@@ -373,18 +373,35 @@ func parseInt64(s string) int64 {
 	return val
 }
 
-func NewSsaMap() *SsaMap {
-	return &SsaMap{
-		DevMap: map[string]SsaLogicalDriveInfo{},
-	}
+// ssaBackend is the RAID backend of HPE Smart Array controllers, based on
+// ssacli tool.
+type ssaBackend struct{}
+
+func newSsaBackend() *ssaBackend {
+	return &ssaBackend{}
+}
+
+func (*ssaBackend) Name() string {
+	return "ssacli"
+}
+
+func (*ssaBackend) Vendor() string {
+	return "hpe"
 }
 
-func executeCommand(command string, arg ...string) (string, error) {
-	cmd := exec.Command(command, arg...)
-	out, err := cmd.Output()
+func (*ssaBackend) Detect() error {
+	_, err := LocateSsa()
+	return err
+}
+
+func (*ssaBackend) Version(ctx context.Context) (string, error) {
+	return RunSsaVersion(ctx)
+}
+
+func (*ssaBackend) Probe(ctx context.Context) ([]RaidController, error) {
+	cfg, err := RunSsaShowConfig(ctx)
 	if err != nil {
-		return string(out), err
+		return nil, err
 	}
-	res := strings.TrimSpace(string(out))
-	return res, nil
+	return ParseSsaRaidControllers(cfg), nil
 }
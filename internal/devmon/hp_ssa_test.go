@@ -65,8 +65,6 @@ Smart HBA H240 in Slot 1 (RAID Mode)
    Primary Boot Volume: logicaldrive 1 (600508B1001C90DB4A1FDCBCCB744F14)
    Secondary Boot Volume: None
 
-
-
    Internal Drive Cage at Port 1I, Box 1, OK
 
       Drive Bays: 4
@@ -78,7 +76,6 @@ Smart HBA H240 in Slot 1 (RAID Mode)
       physicaldrive 1I:1:1 (port 1I:box 1:bay 1, SATA HDD, 1 TB, OK)
       physicaldrive 1I:1:2 (port 1I:box 1:bay 2, SATA SSD, 400 GB, OK)
 
-
    Port Name: 1I
          Port ID: 1
          Port Connection Number: 1
@@ -100,7 +97,6 @@ Smart HBA H240 in Slot 1 (RAID Mode)
       Array Type: Data
       Smart Path: enable
 
-
       Logical Drive: 1
          Size: 372.58 GB
          Fault Tolerance: 0
@@ -121,7 +117,6 @@ Smart HBA H240 in Slot 1 (RAID Mode)
          Drive Type: Data
          LD Acceleration Method: Smart Path
 
-
       physicaldrive 1I:1:2
          Port: 1I
          Box: 1
@@ -156,8 +151,6 @@ Smart HBA H240 in Slot 1 (RAID Mode)
          Unrestricted Sanitize Supported: True
          Shingled Magnetic Recording Support: None
 
-
-
    Array: B
       Interface Type: SATA
       Unused Space: 0 MB (0.00%)
@@ -167,7 +160,6 @@ Smart HBA H240 in Slot 1 (RAID Mode)
       Array Type: Data
       Smart Path: disable
 
-
       Logical Drive: 2
          Size: 931.48 GB
          Fault Tolerance: 0
@@ -186,7 +178,6 @@ Smart HBA H240 in Slot 1 (RAID Mode)
          Drive Type: Data
          LD Acceleration Method: All disabled
 
-
       physicaldrive 1I:1:1
          Port: 1I
          Box: 1
@@ -292,8 +283,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
    Primary Boot Volume: None
    Secondary Boot Volume: None
 
-
-
    Internal Drive Cage at Port 1I, Box 2, OK
 
       Drive Bays: 4
@@ -307,8 +296,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
       physicaldrive 1I:2:3 (port 1I:box 2:bay 3, SAS HDD, 6 TB, OK)
       physicaldrive 1I:2:4 (port 1I:box 2:bay 4, SAS HDD, 6 TB, OK)
 
-
-
    Internal Drive Cage at Port 2I, Box 3, OK
 
       Drive Bays: 4
@@ -322,8 +309,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
       physicaldrive 2I:3:3 (port 2I:box 3:bay 3, SAS HDD, 6 TB, OK)
       physicaldrive 2I:3:4 (port 2I:box 3:bay 4, SAS HDD, 6 TB, OK)
 
-
-
    Internal Drive Cage at Port 3I, Box 6, OK
 
       Drive Bays: 4
@@ -335,7 +320,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
       physicaldrive 3I:6:1 (port 3I:box 6:bay 1, SATA SSD, 960 GB, OK)
       physicaldrive 3I:6:2 (port 3I:box 6:bay 2, SATA SSD, 960 GB, OK)
 
-
    Port Name: 1I
          Port ID: 0
          Port Mode: Mixed
@@ -373,7 +357,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
       Array Type: Data
       Smart Path: disable
 
-
       Logical Drive: 1
          Size: 10.92 TB
          Fault Tolerance: 6
@@ -397,7 +380,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
          Drive Type: Data
          LD Acceleration Method: Controller Cache
 
-
       physicaldrive 1I:2:1
          Port: 1I
          Box: 2
@@ -518,8 +500,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
          Shingled Magnetic Recording Support: None
          Drive Unique ID: 5000C50094DDBB83
 
-
-
    Array: B
       Interface Type: SAS
       Unused Space: 1 MB (0.00%)
@@ -529,7 +509,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
       Array Type: Data
       Smart Path: disable
 
-
       Logical Drive: 2
          Size: 10.92 TB
          Fault Tolerance: 6
@@ -550,7 +529,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
          Drive Type: Data
          LD Acceleration Method: Controller Cache
 
-
       physicaldrive 2I:3:1
          Port: 2I
          Box: 3
@@ -671,8 +649,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
          Shingled Magnetic Recording Support: None
          Drive Unique ID: 5000C50094D18743
 
-
-
    Array: C
       Interface Type: Solid State SATA
       Unused Space: 0 MB (0.00%)
@@ -682,7 +658,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
       Array Type: Data
       Smart Path: enable
 
-
       Logical Drive: 3
          Size: 894.22 GB
          Fault Tolerance: 1
@@ -706,7 +681,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
          Drive Type: Data
          LD Acceleration Method: Smart Path
 
-
       physicaldrive 3I:6:1
          Port: 3I
          Box: 6
@@ -783,7 +757,6 @@ HPE Smart Array P816i-a SR Gen10 in Slot 0 (Embedded)
          Shingled Magnetic Recording Support: None
          Drive Unique ID: A4F747699CC33D09
 
-
    SEP (Vendor ID HPE, Model Smart Adapter) 379
       Device Number: 379
       Firmware Version: 1.34
@@ -830,54 +803,41 @@ func TestParseSsaShowConfig(t *testing.T) {
 	assert.Equal(t, len(pd.Values), 29)
 }
 
-func TestParseConfigToLogical(t *testing.T) {
-	cfg, err := devmon.ParseSsaShowConfig(ssacliCtrlAllShowConfigDetail)
-	assert.NoError(t, err)
-	assert.NotNil(t, cfg)
-
-	ldm, err := devmon.ParseConfigToLogical(cfg)
-	assert.NoError(t, err)
-	assert.Equal(t, len(ldm.DevMap), 2)
-	ldia := ldm.DevMap["sda"]
-	assert.Equal(t, ldia.DiskName, "/dev/sda")
-	assert.Equal(t, ldia.Size, "372.58 GB")
-	assert.Greater(t, ldia.SizeBytes, uint64(372)*devmon.Giga)
-	assert.Greater(t, uint64(373)*devmon.Giga, ldia.SizeBytes)
-	ldib := ldm.DevMap["sdb"]
-	assert.Equal(t, ldib.DiskName, "/dev/sdb")
-	assert.Equal(t, ldib.Size, "931.48 GB")
-	assert.Greater(t, ldib.SizeBytes, uint64(931)*devmon.Giga)
-	assert.Greater(t, uint64(932)*devmon.Giga, ldib.SizeBytes)
-
-	for _, ldi := range ldm.DevMap {
-		for _, pdi := range ldi.PhysicalDrives {
-			assert.Greater(t, pdi.SizeBytes, uint64(0))
-			assert.Greater(t, pdi.TempCurr, int64(0))
-			assert.Greater(t, pdi.TempMaxi, int64(0))
-			assert.True(t, (pdi.PowerHours > 0) || (pdi.PowerHours == -1))
-		}
-	}
-}
-
-func TestParseConfigToLogical2(t *testing.T) {
+func TestParseSsaRaidControllers(t *testing.T) {
 	cfg, err := devmon.ParseSsaShowConfig(ssacliCtrlAllShowConfigDetail2)
 	assert.NoError(t, err)
-	assert.NotNil(t, cfg)
 
-	ldm, err := devmon.ParseConfigToLogical(cfg)
-	assert.NoError(t, err)
-	assert.Equal(t, len(ldm.DevMap), 3)
-
-	ldb := ldm.DevMap["sdb"]
-	assert.Equal(t, ldb.UniqueID, "600508B1001CCD7B72DB95E459CDDCCC")
-	assert.Equal(t, len(ldb.PhysicalDrives), 4)
-
-	for _, ldi := range ldm.DevMap {
-		for _, pdi := range ldi.PhysicalDrives {
-			assert.Greater(t, pdi.SizeBytes, uint64(0))
-			assert.Greater(t, pdi.TempCurr, int64(0))
-			assert.Greater(t, pdi.TempMaxi, int64(0))
-			assert.True(t, (pdi.PowerHours > 0) || (pdi.PowerHours == -1))
-		}
-	}
+	ctrls := devmon.ParseSsaRaidControllers(cfg)
+	assert.Equal(t, len(ctrls), 1)
+	ctrl := ctrls[0]
+	assert.Equal(t, ctrl.ID, "0")
+	assert.Equal(t, ctrl.Model, "HPE Smart Array P816i-a SR Gen10")
+	assert.Equal(t, ctrl.Serial, "PEYHD0CRHB00IZ")
+	assert.Equal(t, ctrl.Status, "OK")
+	assert.Equal(t, ctrl.CacheStatus, "OK")
+	assert.Equal(t, ctrl.BatteryStatus, "OK")
+	assert.Equal(t, ctrl.Temperature, int64(58))
+	assert.Equal(t, len(ctrl.Arrays), 3)
+
+	arr := ctrl.Arrays[2]
+	assert.Equal(t, arr.Name, "C")
+	assert.Equal(t, arr.Status, "OK")
+	assert.Equal(t, len(arr.LogicalDrives), 1)
+	assert.Equal(t, len(arr.PhysicalDrives), 2)
+	ld := arr.LogicalDrives[0]
+	assert.Equal(t, ld.ID, "3")
+	assert.Equal(t, ld.DiskName, "/dev/sdd")
+	assert.Equal(t, ld.RaidLevel, "1")
+	assert.Equal(t, ld.UniqueID, "600508B1001C32B269EB8948F3E5A8E4")
+	assert.Equal(t, len(ld.PhysicalDrives), 2)
+	pd := ld.PhysicalDrives[0]
+	assert.Equal(t, pd.ID, "physicaldrive 3I:6:1")
+	assert.Equal(t, pd.Serial, "BTYS825203G8960CGN")
+	assert.Equal(t, pd.Model, "ATA VK000960GWJPF")
+	assert.Equal(t, pd.MediaType, "SSD")
+	assert.Equal(t, pd.UsageRemaining, 97.78)
+
+	pd = ctrl.Arrays[0].PhysicalDrives[0]
+	assert.Equal(t, pd.MediaType, "HDD")
+	assert.Equal(t, pd.UsageRemaining, float64(-1))
 }
@@ -21,12 +21,14 @@ const (
 	PodNamespaceEnvKey = "POD_NAMESPACE"
 	PodIPEnvKey        = "POD_IP"
 	HostIPEnvKey       = "HOST_IP"
+	NodeNameEnvKey     = "NODE_NAME"
 )
 
 type Ident struct {
 	Progname  string     `json:"program"`
 	Version   string     `json:"version"`
 	Hostname  string     `json:"hostname"`
+	Nodename  string     `json:"nodename"`
 	Name      string     `json:"name"`
 	Namespace string     `json:"namespace"`
 	HostIP    string     `json:"hostip"`
@@ -34,11 +36,23 @@ type Ident struct {
 	User      *user.User `json:"user"`
 }
 
+// SelfIdent returns the identity of current process; when running as a
+// Kubernetes pod, its name and addresses are taken from the pod's environment
+// (via downward API), otherwise only host's name is known.
 func SelfIdent() *Ident {
+	hostname := os.Getenv(HostnameEnvKey)
+	if hostname == "" {
+		hostname = selfHostname()
+	}
+	nodename := os.Getenv(NodeNameEnvKey)
+	if nodename == "" {
+		nodename = selfHostname()
+	}
 	return &Ident{
 		Progname:  Progname(),
 		Version:   Version(),
-		Hostname:  os.Getenv(HostnameEnvKey),
+		Hostname:  hostname,
+		Nodename:  nodename,
 		Name:      os.Getenv(PodNameEnvKey),
 		Namespace: os.Getenv(PodNamespaceEnvKey),
 		PodIP:     os.Getenv(PodIPEnvKey),
@@ -47,6 +61,19 @@ func SelfIdent() *Ident {
 	}
 }
 
+// selfHostname returns the host's name, falling back to kernel's nodename
+func selfHostname() string {
+	hostname, err := os.Hostname()
+	if err == nil && hostname != "" {
+		return hostname
+	}
+	uname, err := Uname()
+	if err != nil {
+		return "unknown"
+	}
+	return uname.Nodename
+}
+
 func currentUser() *user.User {
 	currUser, err := user.Current()
 	if err != nil || currUser == nil {
@@ -12,6 +12,8 @@ func TestSelfIdent(t *testing.T) {
 	assert.NotNil(t, ident)
 	assert.NotNil(t, ident.User)
 	assert.NotEqual(t, ident.Progname, "")
+	assert.NotEqual(t, ident.Hostname, "")
+	assert.NotEqual(t, ident.Nodename, "")
 	assert.NotEqual(t, ident.User.Uid, "")
 	assert.NotEqual(t, ident.User.Gid, "")
 }
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+const (
+	InventoryGroup   = "storage.hpe.com"
+	InventoryVersion = "v1alpha1"
+	InventoryKind    = "StorageNodeInventory"
+)
+
+// InventoryResource is the resource of StorageNodeInventory custom resources
+var InventoryResource = schema.GroupVersionResource{
+	Group:    InventoryGroup,
+	Version:  InventoryVersion,
+	Resource: "storagenodeinventories",
+}
+
+// StorageNodeInventorySpec is the spec of StorageNodeInventory custom
+// resource: the (vendor-neutral) model of node's RAID controllers, with their
+// arrays, logical and physical drives. It holds only static properties and
+// status, without telemetry (e.g. temperatures), so that it is updated only
+// upon hardware or status changes.
+type StorageNodeInventorySpec struct {
+	NodeName    string                    `json:"nodeName"`
+	Health      string                    `json:"health"`
+	Controllers []NodeInventoryController `json:"controllers"`
+}
+
+// NodeInventoryController is RAID controller of StorageNodeInventory
+type NodeInventoryController struct {
+	Backend       string               `json:"backend"`
+	Vendor        string               `json:"vendor"`
+	ID            string               `json:"id"`
+	Model         string               `json:"model"`
+	Serial        string               `json:"serial"`
+	Firmware      string               `json:"firmware"`
+	Status        string               `json:"status"`
+	CacheStatus   string               `json:"cachestatus"`
+	BatteryStatus string               `json:"batterystatus"`
+	Arrays        []NodeInventoryArray `json:"arrays"`
+}
+
+// NodeInventoryArray is RAID array of StorageNodeInventory; its physical
+// drives are listed once, under the array, rather than under each of its
+// logical drives.
+type NodeInventoryArray struct {
+	Name           string                       `json:"name"`
+	Status         string                       `json:"status"`
+	LogicalDrives  []NodeInventoryLogicalDrive  `json:"logicaldrives"`
+	PhysicalDrives []NodeInventoryPhysicalDrive `json:"physicaldrives"`
+}
+
+// NodeInventoryLogicalDrive is RAID logical drive of StorageNodeInventory
+type NodeInventoryLogicalDrive struct {
+	ID        string `json:"id"`
+	DiskName  string `json:"diskname"`
+	SizeBytes uint64 `json:"sizebytes"`
+	Status    string `json:"status"`
+	UniqueID  string `json:"uniqueid"`
+	RaidLevel string `json:"raidlevel"`
+}
+
+// NodeInventoryPhysicalDrive is RAID physical drive of StorageNodeInventory
+type NodeInventoryPhysicalDrive struct {
+	ID        string `json:"id"`
+	Box       string `json:"box"`
+	Bay       string `json:"bay"`
+	Model     string `json:"model"`
+	Serial    string `json:"serial"`
+	Firmware  string `json:"firmware"`
+	MediaType string `json:"mediatype"`
+	Interface string `json:"interface"`
+	SizeBytes uint64 `json:"sizebytes"`
+	Status    string `json:"status"`
+	UniqueID  string `json:"uniqueid"`
+}
+
+// NewStorageNodeInventorySpec returns the inventory spec of node's RAID
+// controllers.
+func NewStorageNodeInventorySpec(nodename string,
+	ctrls []RaidController) *StorageNodeInventorySpec {
+	spec := &StorageNodeInventorySpec{
+		NodeName:    nodename,
+		Health:      NewRaidHealthSummary(ctrls).Health,
+		Controllers: []NodeInventoryController{},
+	}
+	for _, ctrl := range ctrls {
+		ic := NodeInventoryController{
+			Backend:       ctrl.Backend,
+			Vendor:        ctrl.Vendor,
+			ID:            ctrl.ID,
+			Model:         ctrl.Model,
+			Serial:        ctrl.Serial,
+			Firmware:      ctrl.Firmware,
+			Status:        ctrl.Status,
+			CacheStatus:   ctrl.CacheStatus,
+			BatteryStatus: ctrl.BatteryStatus,
+			Arrays:        []NodeInventoryArray{},
+		}
+		for _, arr := range ctrl.Arrays {
+			ic.Arrays = append(ic.Arrays, newNodeInventoryArray(&arr))
+		}
+		spec.Controllers = append(spec.Controllers, ic)
+	}
+	return spec
+}
+
+func newNodeInventoryArray(arr *RaidArray) NodeInventoryArray {
+	ia := NodeInventoryArray{
+		Name:           arr.Name,
+		Status:         arr.Status,
+		LogicalDrives:  []NodeInventoryLogicalDrive{},
+		PhysicalDrives: []NodeInventoryPhysicalDrive{},
+	}
+	for _, ld := range arr.LogicalDrives {
+		ia.LogicalDrives = append(ia.LogicalDrives, NodeInventoryLogicalDrive{
+			ID:        ld.ID,
+			DiskName:  ld.DiskName,
+			SizeBytes: ld.SizeBytes,
+			Status:    ld.Status,
+			UniqueID:  ld.UniqueID,
+			RaidLevel: ld.RaidLevel,
+		})
+	}
+	for _, pd := range arr.PhysicalDrives {
+		ia.PhysicalDrives = append(ia.PhysicalDrives, NodeInventoryPhysicalDrive{
+			ID:        pd.ID,
+			Box:       pd.Box,
+			Bay:       pd.Bay,
+			Model:     pd.Model,
+			Serial:    pd.Serial,
+			Firmware:  pd.Firmware,
+			MediaType: pd.MediaType,
+			Interface: pd.Interface,
+			SizeBytes: pd.SizeBytes,
+			Status:    pd.Status,
+			UniqueID:  pd.UniqueID,
+		})
+	}
+	return ia
+}
+
+// NewStorageNodeInventory returns StorageNodeInventory custom resource of
+// node, named after the node and owned by it (hence garbage collected upon
+// node's deletion).
+func NewStorageNodeInventory(node *corev1.Node,
+	ctrls []RaidController) (*unstructured.Unstructured, error) {
+	spec, err := unstructuredInventorySpec(NewStorageNodeInventorySpec(node.Name, ctrls))
+	if err != nil {
+		return nil, err
+	}
+	inv := &unstructured.Unstructured{}
+	inv.SetAPIVersion(InventoryGroup + "/" + InventoryVersion)
+	inv.SetKind(InventoryKind)
+	inv.SetName(node.Name)
+	inv.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: "v1",
+			Kind:       "Node",
+			Name:       node.Name,
+			UID:        node.UID,
+		},
+	})
+	inv.Object["spec"] = spec
+	return inv, nil
+}
+
+func unstructuredInventorySpec(
+	invspec *StorageNodeInventorySpec) (map[string]interface{}, error) {
+	// round-trip via JSON, as unstructured content may not hold uint64 values
+	dat, err := json.Marshal(invspec)
+	if err != nil {
+		return nil, err
+	}
+	spec := map[string]interface{}{}
+	if err := utiljson.Unmarshal(dat, &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// applyInventory creates the StorageNodeInventory of local node when missing
+// (e.g. deleted by user), or updates it when its spec differs from the last
+// successful update.
+func (nr *nodeReporter) applyInventory(ctx context.Context, ctrls []RaidController) error {
+	invspec := NewStorageNodeInventorySpec(nr.nodename, ctrls)
+	dat, err := json.Marshal(invspec)
+	if err != nil {
+		return err
+	}
+	res := nr.dyn.Resource(InventoryResource)
+	inv, err := res.Get(ctx, nr.nodename, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		node, err := nr.cset.CoreV1().Nodes().Get(ctx, nr.nodename, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		inv, err = NewStorageNodeInventory(node, ctrls)
+		if err != nil {
+			return err
+		}
+		if _, err = res.Create(ctx, inv, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		nr.log.Info("created inventory", "node", nr.nodename)
+	} else if err != nil {
+		return err
+	} else if string(dat) == nr.lastInventory {
+		return nil
+	} else {
+		spec, err := unstructuredInventorySpec(invspec)
+		if err != nil {
+			return err
+		}
+		inv.Object["spec"] = spec
+		if _, err = res.Update(ctx, inv, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		nr.log.Info("updated inventory", "node", nr.nodename)
+	}
+	nr.lastInventory = string(dat)
+	return nil
+}
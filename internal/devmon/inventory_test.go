@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStorageNodeInventory(t *testing.T) {
+	cfg, err := devmon.ParseSsaShowConfig(ssacliCtrlAllShowConfigDetail2)
+	assert.NoError(t, err)
+	ctrls := devmon.ParseSsaRaidControllers(cfg)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "worker-0",
+			UID:  "9f3c4c1e-1d2b-4c0e-8a52-0f4b0e3b1a11",
+		},
+	}
+	inv, err := devmon.NewStorageNodeInventory(node, ctrls)
+	assert.NoError(t, err)
+	assert.Equal(t, "storage.hpe.com/v1alpha1", inv.GetAPIVersion())
+	assert.Equal(t, "StorageNodeInventory", inv.GetKind())
+	assert.Equal(t, "worker-0", inv.GetName())
+	assert.Equal(t, "", inv.GetNamespace())
+
+	owners := inv.GetOwnerReferences()
+	assert.Equal(t, 1, len(owners))
+	assert.Equal(t, "Node", owners[0].Kind)
+	assert.Equal(t, node.UID, owners[0].UID)
+
+	nodeName, _, _ := unstructured.NestedString(inv.Object, "spec", "nodeName")
+	assert.Equal(t, "worker-0", nodeName)
+	health, _, _ := unstructured.NestedString(inv.Object, "spec", "health")
+	assert.Equal(t, devmon.RaidHealthOK, health)
+
+	uctrls, _, _ := unstructured.NestedSlice(inv.Object, "spec", "controllers")
+	assert.Equal(t, 1, len(uctrls))
+	uctrl := uctrls[0].(map[string]interface{})
+	assert.Equal(t, "PEYHD0CRHB00IZ", uctrl["serial"])
+	assert.Equal(t, "HPE Smart Array P816i-a SR Gen10", uctrl["model"])
+	uarrs, _, _ := unstructured.NestedSlice(uctrl, "arrays")
+	assert.Equal(t, 3, len(uarrs))
+	uarr := uarrs[0].(map[string]interface{})
+	upds, _, _ := unstructured.NestedSlice(uarr, "physicaldrives")
+	assert.NotEmpty(t, upds)
+	upd := upds[0].(map[string]interface{})
+	assert.NotEmpty(t, upd["serial"])
+	assert.NotContains(t, upd, "tempcurr")
+	assert.NotContains(t, upd, "powerhours")
+	ulds, _, _ := unstructured.NestedSlice(uarr, "logicaldrives")
+	assert.NotEmpty(t, ulds)
+	assert.NotContains(t, ulds[0].(map[string]interface{}), "physicaldrives")
+
+	// deep-copy fails on values which are not valid JSON types
+	assert.NotNil(t, inv.DeepCopy())
+}
+
+func TestStorageNodeInventorySpecTelemetry(t *testing.T) {
+	cfg, err := devmon.ParseSsaShowConfig(ssacliCtrlAllShowConfigDetail2)
+	assert.NoError(t, err)
+	ctrls := devmon.ParseSsaRaidControllers(cfg)
+	spec := devmon.NewStorageNodeInventorySpec("worker-0", ctrls)
+
+	// spec is unaffected by changes of drives' and controller's telemetry
+	ctrls2 := devmon.ParseSsaRaidControllers(cfg)
+	ctrls2[0].Temperature++
+	for i := range ctrls2[0].Arrays {
+		arr := &ctrls2[0].Arrays[i]
+		for j := range arr.PhysicalDrives {
+			arr.PhysicalDrives[j].TempCurr++
+			arr.PhysicalDrives[j].PowerHours++
+			arr.PhysicalDrives[j].UsageRemaining--
+		}
+	}
+	assert.Equal(t, spec, devmon.NewStorageNodeInventorySpec("worker-0", ctrls2))
+
+	// yet it reflects status changes
+	ctrls2[0].Arrays[0].PhysicalDrives[0].Status = "Failed"
+	spec2 := devmon.NewStorageNodeInventorySpec("worker-0", ctrls2)
+	assert.NotEqual(t, spec, spec2)
+	assert.Equal(t, devmon.RaidHealthDegraded, spec2.Health)
+}
+
+func TestApplyInventory(t *testing.T) {
+	cfg, err := devmon.ParseSsaShowConfig(ssacliCtrlAllShowConfigDetail2)
+	assert.NoError(t, err)
+	ctrls := devmon.ParseSsaRaidControllers(cfg)
+
+	ctx := context.Background()
+	cset := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-0"},
+	})
+	dyn := dynfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			devmon.InventoryResource: "StorageNodeInventoryList",
+		})
+	res := dyn.Resource(devmon.InventoryResource)
+	ir := devmon.NewInventoryReporter(cset, dyn, "worker-0")
+
+	assert.NoError(t, ir.ApplyInventory(ctx, ctrls))
+	_, err = res.Get(ctx, "worker-0", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// unchanged spec is not updated
+	actions := len(dyn.Actions())
+	assert.NoError(t, ir.ApplyInventory(ctx, ctrls))
+	for _, action := range dyn.Actions()[actions:] {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+
+	// deleted inventory is re-created, even with unchanged spec
+	assert.NoError(t, res.Delete(ctx, "worker-0", metav1.DeleteOptions{}))
+	assert.NoError(t, ir.ApplyInventory(ctx, ctrls))
+	inv, err := res.Get(ctx, "worker-0", metav1.GetOptions{})
+	assert.NoError(t, err)
+	nodeName, _, _ := unstructured.NestedString(inv.Object, "spec", "nodeName")
+	assert.Equal(t, "worker-0", nodeName)
+}
@@ -11,6 +11,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -18,6 +19,7 @@ import (
 
 type client struct {
 	ClientSet *kubernetes.Clientset
+	Dynamic   dynamic.Interface
 	Config    *rest.Config
 }
 
@@ -26,15 +28,7 @@ func newClient() (*client, error) {
 	if err != nil {
 		return newExternalClient()
 	}
-	cset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return &client{}, err
-	}
-
-	return &client{
-		ClientSet: cset,
-		Config:    config,
-	}, nil
+	return newClientFor(config)
 }
 
 func newExternalClient() (*client, error) {
@@ -42,13 +36,22 @@ func newExternalClient() (*client, error) {
 	if err != nil {
 		return &client{}, err
 	}
+	return newClientFor(config)
+}
+
+func newClientFor(config *rest.Config) (*client, error) {
 	cset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return &client{}, err
 	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return &client{}, err
+	}
 
 	return &client{
 		ClientSet: cset,
+		Dynamic:   dyn,
 		Config:    config,
 	}, nil
 }
@@ -94,7 +97,7 @@ func ListAllRunningPods(ctx context.Context, clnt *client) ([]*corev1.Pod, error
 func ListRunningPodsWith(ctx context.Context, clnt *client,
 	namePrefix, hostIP string) ([]*corev1.Pod, error) {
 	ret := []*corev1.Pod{}
-	pods, err := ListAllRunningPods(context.TODO(), clnt)
+	pods, err := ListAllRunningPods(ctx, clnt)
 	if err != nil {
 		return ret, err
 	}
@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// NodeHealthLabel is the Node label which reflects the overall health of
+	// node's RAID controllers
+	NodeHealthLabel = "storage.hpe.com/raid-health"
+	// NodeSummaryAnnotation is the Node annotation which carries a JSON
+	// summary of node's RAID controllers and logical drives
+	NodeSummaryAnnotation = "storage.hpe.com/raid-summary"
+	// NodeConditionType is the Node condition which reflects whether node's
+	// RAID controllers are healthy
+	NodeConditionType = corev1.NodeConditionType("StorageHealthy")
+)
+
+// RAID health levels, as reported by node label
+const (
+	RaidHealthOK       = "ok"
+	RaidHealthDegraded = "degraded"
+	RaidHealthFailed   = "failed"
+	RaidHealthUnknown  = "unknown"
+)
+
+// RaidLogicalDriveSummary is the summary of a single logical drive
+type RaidLogicalDriveSummary struct {
+	ID        string `json:"id"`
+	DiskName  string `json:"diskname,omitempty"`
+	RaidLevel string `json:"raidlevel,omitempty"`
+	Status    string `json:"status"`
+}
+
+// RaidControllerSummary is the summary of a single RAID controller
+type RaidControllerSummary struct {
+	ID            string                    `json:"id"`
+	Vendor        string                    `json:"vendor"`
+	Model         string                    `json:"model,omitempty"`
+	Status        string                    `json:"status"`
+	LogicalDrives []RaidLogicalDriveSummary `json:"logicaldrives"`
+}
+
+// RaidHealthSummary is the summary of node's RAID controllers, with overall
+// health level.
+type RaidHealthSummary struct {
+	Health      string                  `json:"health"`
+	Problems    []string                `json:"problems,omitempty"`
+	Controllers []RaidControllerSummary `json:"controllers"`
+}
+
+// NewRaidHealthSummary summarizes the state of RAID controllers: health is
+// failed if any of the arrays or logical drives failed (or is offline),
+// degraded if any of the controllers or drives reports non-OK status, and ok
+// otherwise.
+func NewRaidHealthSummary(ctrls []RaidController) *RaidHealthSummary {
+	ret := &RaidHealthSummary{
+		Health:      RaidHealthOK,
+		Controllers: []RaidControllerSummary{},
+	}
+	for _, ctrl := range ctrls {
+		ret.degradeBy(ctrl.Status)
+		ret.degradeBy(ctrl.CacheStatus)
+		ret.degradeBy(ctrl.BatteryStatus)
+		cs := RaidControllerSummary{
+			ID:            ctrl.ID,
+			Vendor:        ctrl.Vendor,
+			Model:         ctrl.Model,
+			Status:        ctrl.Status,
+			LogicalDrives: []RaidLogicalDriveSummary{},
+		}
+		for _, arr := range ctrl.Arrays {
+			ret.failBy(arr.Status)
+			for _, pd := range arr.PhysicalDrives {
+				ret.degradeBy(pd.Status)
+			}
+			for _, ld := range arr.LogicalDrives {
+				ret.failBy(ld.Status)
+				cs.LogicalDrives = append(cs.LogicalDrives, RaidLogicalDriveSummary{
+					ID:        ld.ID,
+					DiskName:  ld.DiskName,
+					RaidLevel: ld.RaidLevel,
+					Status:    ld.Status,
+				})
+			}
+		}
+		ret.Controllers = append(ret.Controllers, cs)
+	}
+	for _, ent := range raidStatusSnapshot(ctrls) {
+		if ent.status != "OK" {
+			ret.Problems = append(ret.Problems, ent.desc+" status: "+ent.status)
+		}
+	}
+	sort.Strings(ret.Problems)
+	return ret
+}
+
+func (rhs *RaidHealthSummary) degradeBy(status string) {
+	if status != "" && status != "OK" && rhs.Health == RaidHealthOK {
+		rhs.Health = RaidHealthDegraded
+	}
+}
+
+func (rhs *RaidHealthSummary) failBy(status string) {
+	if status == "Failed" || status == "Offline" {
+		rhs.Health = RaidHealthFailed
+		return
+	}
+	rhs.degradeBy(status)
+}
+
+// NewStorageCondition returns the StorageHealthy node condition which
+// corresponds to RAID health summary: True when healthy or degraded (as data is
+// still accessible, though redundancy may be reduced), False when failed, and
+// Unknown otherwise. Transition time is taken from the previous
+// condition (if any) unless its status differs.
+func NewStorageCondition(summary *RaidHealthSummary, prev *corev1.NodeCondition,
+	now time.Time) corev1.NodeCondition {
+	cond := corev1.NodeCondition{
+		Type:              NodeConditionType,
+		LastHeartbeatTime: metav1.NewTime(now),
+	}
+	switch summary.Health {
+	case RaidHealthOK:
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = "RaidHealthy"
+		cond.Message = "RAID controllers and drives are healthy"
+	case RaidHealthDegraded:
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = "RaidDegraded"
+		cond.Message = strings.Join(summary.Problems, "; ")
+	case RaidHealthFailed:
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "RaidFailed"
+		cond.Message = strings.Join(summary.Problems, "; ")
+	default:
+		cond.Status = corev1.ConditionUnknown
+		cond.Reason = "RaidUnknown"
+		cond.Message = "failed to probe RAID controllers"
+	}
+	if prev != nil && prev.Status == cond.Status {
+		cond.LastTransitionTime = prev.LastTransitionTime
+	} else {
+		cond.LastTransitionTime = metav1.NewTime(now)
+	}
+	return cond
+}
+
+// nodeReporter periodically publishes the RAID health of local node as
+// labels and annotations of its Node object and/or as its condition, the
+// node's RAID inventory as custom resource, and events upon RAID status
+// transitions.
+type nodeReporter struct {
+	log           logr.Logger
+	sdp           *storageDevicesProbe
+	cset          kubernetes.Interface
+	dyn           dynamic.Interface
+	nodename      string
+	interval      time.Duration
+	labels        bool
+	condition     bool
+	inventory     bool
+	events        *raidEventRecorder
+	last          string
+	lastInventory string
+	cond          *corev1.NodeCondition
+}
+
+func newNodeReporter(log logr.Logger, sdp *storageDevicesProbe) *nodeReporter {
+	nr := &nodeReporter{
+		log:       log,
+		sdp:       sdp,
+		cset:      sdp.clnt.ClientSet,
+		dyn:       sdp.clnt.Dynamic,
+		nodename:  sdp.ident.Nodename,
+		interval:  sdp.opts.NodeInterval,
+		labels:    sdp.opts.NodeLabels,
+		condition: sdp.opts.NodeCondition,
+		inventory: sdp.opts.Inventory,
+	}
+	if sdp.opts.Events {
+		nr.events = newRaidEventRecorder(log, nr.cset, nr.nodename)
+	}
+	return nr
+}
+
+// run updates the Node upon start and at each interval, until ctx is done
+func (nr *nodeReporter) run(ctx context.Context) {
+	nr.log.Info("start node reporter", "node", nr.nodename, "interval", nr.interval)
+	ticker := time.NewTicker(nr.interval)
+	defer ticker.Stop()
+	for {
+		nr.update(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (nr *nodeReporter) update(ctx context.Context) {
+	ctrls, err := nr.sdp.probeRaidControllers()
+	if err != nil {
+		nr.log.Error(err, "failed to probe RAID controllers")
+	}
+	if nr.events != nil && err == nil {
+		nr.events.observe(ctrls)
+	}
+	summary := nr.summarize(ctrls, err)
+	if nr.labels {
+		if err := nr.patchNode(ctx, summary); err != nil && ctx.Err() == nil {
+			nr.log.Error(err, "failed to update node", "node", nr.nodename)
+		}
+	}
+	if nr.condition {
+		if err := nr.patchCondition(ctx, summary, time.Now()); err != nil && ctx.Err() == nil {
+			nr.log.Error(err, "failed to update node condition", "node", nr.nodename)
+		}
+	}
+	if nr.inventory && err == nil {
+		if err := nr.applyInventory(ctx, ctrls); err != nil && ctx.Err() == nil {
+			nr.log.Error(err, "failed to update inventory", "node", nr.nodename)
+		}
+	}
+}
+
+func (nr *nodeReporter) summarize(ctrls []RaidController, err error) *RaidHealthSummary {
+	if err != nil {
+		return &RaidHealthSummary{
+			Health:      RaidHealthUnknown,
+			Controllers: []RaidControllerSummary{},
+		}
+	}
+	return NewRaidHealthSummary(ctrls)
+}
+
+// patchNode sets node's health label and summary annotation, when those
+// differ from the last successful update.
+func (nr *nodeReporter) patchNode(ctx context.Context, summary *RaidHealthSummary) error {
+	dat, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	if string(dat) == nr.last {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{
+				NodeHealthLabel: summary.Health,
+			},
+			"annotations": map[string]string{
+				NodeSummaryAnnotation: string(dat),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if nr.nodename == "" {
+		return errors.New("unknown node name")
+	}
+	_, err = nr.cset.CoreV1().Nodes().Patch(ctx, nr.nodename,
+		types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	nr.log.Info("updated node", "node", nr.nodename, "health", summary.Health)
+	nr.last = string(dat)
+	return nil
+}
+
+// patchCondition sets (or refreshes the heartbeat of) node's StorageHealthy
+// condition. Upon first update, the previous condition is looked up on the
+// Node, to preserve its transition time across restarts.
+func (nr *nodeReporter) patchCondition(ctx context.Context,
+	summary *RaidHealthSummary, now time.Time) error {
+	if nr.nodename == "" {
+		return errors.New("unknown node name")
+	}
+	if nr.cond == nil {
+		node, err := nr.cset.CoreV1().Nodes().Get(ctx, nr.nodename, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for i := range node.Status.Conditions {
+			if node.Status.Conditions[i].Type == NodeConditionType {
+				nr.cond = &node.Status.Conditions[i]
+			}
+		}
+	}
+	cond := NewStorageCondition(summary, nr.cond, now)
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.NodeCondition{cond},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = nr.cset.CoreV1().Nodes().PatchStatus(ctx, nr.nodename, patch)
+	if err != nil {
+		return err
+	}
+	if nr.cond == nil || nr.cond.Status != cond.Status {
+		nr.log.Info("updated node condition", "node", nr.nodename,
+			"type", cond.Type, "status", cond.Status, "reason", cond.Reason)
+	}
+	nr.cond = &cond
+	return nil
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newTestRaidController(arrStatus, ldStatus, pdStatus string) devmon.RaidController {
+	return devmon.RaidController{
+		Backend: "ssacli",
+		Vendor:  "hpe",
+		ID:      "0",
+		Model:   "Smart Array P408i-a SR Gen10",
+		Status:  "OK",
+		Arrays: []devmon.RaidArray{
+			{
+				Name:   "A",
+				Status: arrStatus,
+				LogicalDrives: []devmon.RaidLogicalDrive{
+					{
+						ID:        "1",
+						DiskName:  "/dev/sda",
+						RaidLevel: "RAID 1",
+						Status:    ldStatus,
+					},
+				},
+				PhysicalDrives: []devmon.RaidPhysicalDrive{
+					{ID: "physicaldrive 1I:1:1", Status: "OK"},
+					{ID: "physicaldrive 1I:1:2", Status: pdStatus},
+				},
+			},
+		},
+	}
+}
+
+func TestRaidHealthSummary(t *testing.T) {
+	summary := devmon.NewRaidHealthSummary([]devmon.RaidController{})
+	assert.Equal(t, devmon.RaidHealthOK, summary.Health)
+	assert.Equal(t, 0, len(summary.Controllers))
+
+	testCases := []struct {
+		arrStatus string
+		ldStatus  string
+		pdStatus  string
+		health    string
+	}{
+		{"OK", "OK", "OK", devmon.RaidHealthOK},
+		{"OK", "OK", "", devmon.RaidHealthOK},
+		{"OK", "OK", "Predictive Failure", devmon.RaidHealthDegraded},
+		{"Degraded", "Interim Recovery Mode", "Failed", devmon.RaidHealthDegraded},
+		{"Failed", "Failed", "Failed", devmon.RaidHealthFailed},
+		{"OK", "Offline", "OK", devmon.RaidHealthFailed},
+	}
+	for _, tc := range testCases {
+		ctrl := newTestRaidController(tc.arrStatus, tc.ldStatus, tc.pdStatus)
+		summary = devmon.NewRaidHealthSummary([]devmon.RaidController{ctrl})
+		assert.Equal(t, tc.health, summary.Health, tc)
+		assert.Equal(t, 1, len(summary.Controllers))
+		assert.Equal(t, "0", summary.Controllers[0].ID)
+		assert.Equal(t, 1, len(summary.Controllers[0].LogicalDrives))
+		assert.Equal(t, tc.ldStatus, summary.Controllers[0].LogicalDrives[0].Status)
+	}
+}
+
+func TestStorageCondition(t *testing.T) {
+	now := time.Now()
+	ok := devmon.NewRaidHealthSummary([]devmon.RaidController{
+		newTestRaidController("OK", "OK", "OK"),
+	})
+	cond := devmon.NewStorageCondition(ok, nil, now)
+	assert.Equal(t, devmon.NodeConditionType, cond.Type)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "RaidHealthy", cond.Reason)
+	assert.True(t, cond.LastTransitionTime.Time.Equal(now))
+
+	later := now.Add(time.Minute)
+	cond2 := devmon.NewStorageCondition(ok, &cond, later)
+	assert.Equal(t, corev1.ConditionTrue, cond2.Status)
+	assert.True(t, cond2.LastTransitionTime.Time.Equal(now))
+	assert.True(t, cond2.LastHeartbeatTime.Time.Equal(later))
+
+	degraded := devmon.NewRaidHealthSummary([]devmon.RaidController{
+		newTestRaidController("OK", "Interim Recovery Mode", "Failed"),
+	})
+	condd := devmon.NewStorageCondition(degraded, &cond2, later)
+	assert.Equal(t, corev1.ConditionTrue, condd.Status)
+	assert.Equal(t, "RaidDegraded", condd.Reason)
+	assert.Contains(t, condd.Message, "physical drive")
+	assert.True(t, condd.LastTransitionTime.Time.Equal(now))
+
+	failed := devmon.NewRaidHealthSummary([]devmon.RaidController{
+		newTestRaidController("Failed", "Failed", "Failed"),
+	})
+	cond3 := devmon.NewStorageCondition(failed, &cond2, later)
+	assert.Equal(t, corev1.ConditionFalse, cond3.Status)
+	assert.Equal(t, "RaidFailed", cond3.Reason)
+	assert.Contains(t, cond3.Message, "logical drive 1 (/dev/sda) of controller 0 status: Failed")
+	assert.True(t, cond3.LastTransitionTime.Time.Equal(later))
+
+	unknown := &devmon.RaidHealthSummary{Health: devmon.RaidHealthUnknown}
+	cond4 := devmon.NewStorageCondition(unknown, &cond3, later)
+	assert.Equal(t, corev1.ConditionUnknown, cond4.Status)
+}
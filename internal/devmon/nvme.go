@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+var (
+	nvmeNamespaceRe = regexp.MustCompile(`^(nvme\d+)(c\d+)?(n\d+)$`)
+)
+
+// NvmeControllers parses raw information of each NVMe controller under
+// /sys/class/nvme/<ctrl>/..., including its namespaces block devices.
+// https://www.kernel.org/doc/Documentation/ABI/testing/sysfs-class-nvme
+func (sysfs *SysFS) NvmeControllers() ([]NvmeControllerInfo, error) {
+	ret := []NvmeControllerInfo{}
+	ctrls, err := sysfs.ReadDir("class", "nvme")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ret, nil // OK -- no NVMe on this host
+		}
+		return ret, err
+	}
+	for _, ctrl := range ctrls {
+		nci, err := sysfs.NvmeController(filepath.Base(ctrl))
+		if err == nil {
+			ret = append(ret, *nci)
+		}
+	}
+	return ret, nil
+}
+
+// NvmeController parses raw information from under /sys/class/nvme/<ctrl>/...
+func (sysfs *SysFS) NvmeController(ctrl string) (*NvmeControllerInfo, error) {
+	var err error
+	ret := &NvmeControllerInfo{Name: ctrl}
+	pfs := sysfs.Sub(filepath.Join("class", "nvme", ctrl))
+
+	if ret.Model, err = pfs.ReadFileTrim("model"); err != nil {
+		return ret, err
+	}
+	if ret.Serial, err = pfs.ReadFileTrim("serial"); err != nil {
+		ret.Serial = ""
+	}
+	if ret.FirmwareRev, err = pfs.ReadFileTrim("firmware_rev"); err != nil {
+		ret.FirmwareRev = ""
+	}
+	if ret.State, err = pfs.ReadFileTrim("state"); err != nil {
+		ret.State = ""
+	}
+	if ret.Transport, err = pfs.ReadFileTrim("transport"); err != nil {
+		ret.Transport = ""
+	}
+	if ret.Cntlid, err = pfs.ReadFileAsUInt32("cntlid"); err != nil {
+		ret.Cntlid = 0
+	}
+	ret.Namespaces = sysfs.nvmeNamespaces(pfs)
+	return ret, nil
+}
+
+// nvmeNamespaces lists the block devices of controller's namespaces. With
+// native NVMe multipath, per-path entries (nvme<S>c<C>n<N>) are mapped to
+// the multipath head block device (nvme<S>n<N>).
+func (sysfs *SysFS) nvmeNamespaces(pfs *PseudoFS) []string {
+	ret := []string{}
+	ents, err := pfs.ReadDir()
+	if err != nil {
+		return ret
+	}
+	seen := map[string]bool{}
+	for _, ent := range ents {
+		match := nvmeNamespaceRe.FindStringSubmatch(filepath.Base(ent))
+		if match == nil {
+			continue
+		}
+		name := match[1] + match[3]
+		if !seen[name] {
+			seen[name] = true
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+var (
+	// DefaultUdevProperties is the default allowlist of udev properties which
+	// are exposed as labels of block-device info metric.
+	DefaultUdevProperties = []string{
+		"ID_SERIAL",
+		"ID_WWN",
+		"ID_PATH",
+		"ID_FS_TYPE",
+		"ID_MODEL",
+	}
+)
+
+var (
+	// blkdevInfoLabels are the fixed labels of block-device info metric,
+	// which may not be overridden by udev properties labels (nor by nodename
+	// label, see --nodename-label)
+	blkdevInfoLabels = []string{"name", "wwn", "serial"}
+)
+
+const (
+	DefaultHostRoot       = "/"
+	DefaultScrapeInterval = 3 * time.Minute
+	DefaultNodeInterval   = 5 * time.Minute
+)
+
+// Kubernetes integration modes: in auto mode, the exporter falls back to
+// standalone mode when Kubernetes API or pod's environment is unavailable.
+const (
+	KubernetesAuto = "auto"
+	KubernetesOn   = "on"
+	KubernetesOff  = "off"
+)
+
+// defaultCollectors maps the name of each of the exporter's collectors to
+// whether it is enabled by default.
+var defaultCollectors = map[string]bool{
+	"blkdev":          true,
+	"blkdev_io":       true,
+	"blkdev_mount":    true,
+	"nvme":            true,
+	"loadavg":         true,
+	"pressure":        true,
+	"pv":              true,
+	"raid_backend":    true,
+	"raid_controller": true,
+	"raid_logical":    true,
+	"raid_physical":   true,
+	"smartctl":        false,
+	"go":              false,
+	"process":         false,
+}
+
+// CollectorNames returns the sorted names of all of the exporter's collectors
+func CollectorNames() []string {
+	names := []string{}
+	for name := range defaultCollectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultCollectorEnabled returns whether a collector is enabled by default
+func DefaultCollectorEnabled(name string) bool {
+	return defaultCollectors[name]
+}
+
+// Options represents the run-time configuration of devices exporter.
+type Options struct {
+	// MetricsPort is the TCP port on which metrics are served
+	MetricsPort int
+	// HostRoot is the path under which host's /sys, /dev, /proc and /run/udev
+	// are mounted
+	HostRoot string
+	// UdevProperties is the allowlist of udev properties exposed as labels
+	UdevProperties []string
+	// Collectors maps collector name to whether it is enabled
+	Collectors map[string]bool
+	// ScrapeInterval is the expected interval between metrics scrapes, used
+	// to detect stale (or wedged) exporter
+	ScrapeInterval time.Duration
+	// RedfishSecretDir is the path where BMC endpoint and credentials Secret
+	// is mounted; empty value disables the Redfish backend
+	RedfishSecretDir string
+	// WebConfigFile is the path of exporter-toolkit web configuration file,
+	// which enables TLS and/or basic authentication
+	WebConfigFile string
+	// NodenameLabel enables constant nodename label on all metrics
+	NodenameLabel bool
+	// Events enables Kubernetes Events upon RAID status transitions
+	Events bool
+	// NodeLabels enables publishing RAID health as Node labels and
+	// annotations
+	NodeLabels bool
+	// NodeCondition enables maintaining StorageHealthy Node condition
+	NodeCondition bool
+	// Inventory enables maintaining StorageNodeInventory custom resource
+	Inventory bool
+	// NodeInterval is the interval between Node updates (and RAID probes for
+	// events)
+	NodeInterval time.Duration
+	// Kubernetes is the Kubernetes integration mode (auto, on or off)
+	Kubernetes string
+	// TokenReview enables authentication and authorization of metrics
+	// requests by their bearer token, via Kubernetes API
+	TokenReview bool
+}
+
+func NewOptions() *Options {
+	opts := &Options{
+		MetricsPort:    DefaultMetricsPort,
+		HostRoot:       DefaultHostRoot,
+		UdevProperties: DefaultUdevProperties,
+		Collectors:     map[string]bool{},
+		ScrapeInterval: DefaultScrapeInterval,
+		NodeInterval:   DefaultNodeInterval,
+		Events:         true,
+		Kubernetes:     KubernetesAuto,
+	}
+	for name, enabled := range defaultCollectors {
+		opts.Collectors[name] = enabled
+	}
+	return opts
+}
+
+// ParseUdevProperties validates the allowlist of udev properties, and returns
+// it without duplicates: each property must map to a valid label name, which
+// does not collide with any of the fixed labels or with another property's.
+func ParseUdevProperties(props []string) ([]string, error) {
+	ret := []string{}
+	labels := map[string]string{}
+	for _, label := range append([]string{"nodename"}, blkdevInfoLabels...) {
+		labels[label] = ""
+	}
+	for _, prop := range props {
+		prop = strings.TrimSpace(prop)
+		if containsString(ret, prop) {
+			continue
+		}
+		label := udevPropertyLabel(prop)
+		if !model.LabelNameRE.MatchString(label) || strings.HasPrefix(label, "__") {
+			return nil, fmt.Errorf("invalid udev property: %q", prop)
+		}
+		if other, ok := labels[label]; ok {
+			if other == "" {
+				return nil, fmt.Errorf("udev property %q collides with label %q", prop, label)
+			}
+			return nil, fmt.Errorf("udev property %q collides with %q", prop, other)
+		}
+		labels[label] = prop
+		ret = append(ret, prop)
+	}
+	return ret, nil
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUdevProperties(t *testing.T) {
+	props, err := devmon.ParseUdevProperties(devmon.DefaultUdevProperties)
+	assert.NoError(t, err)
+	assert.Equal(t, devmon.DefaultUdevProperties, props)
+
+	props, err = devmon.ParseUdevProperties([]string{"ID_FS_TYPE", "ID_MODEL", "ID_FS_TYPE"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ID_FS_TYPE", "ID_MODEL"}, props)
+
+	props, err = devmon.ParseUdevProperties([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(props))
+
+	for _, bad := range [][]string{
+		{""},
+		{"1ID"},
+		{"__NAME"},
+		{"SERIAL"},
+		{"WWN"},
+		{"NODENAME"},
+		{"ID_FS-TYPE", "ID_FS_TYPE"},
+	} {
+		_, err = devmon.ParseUdevProperties(bad)
+		assert.Error(t, err, bad)
+	}
+}
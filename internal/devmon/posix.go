@@ -22,6 +22,19 @@ func Uname() (*SysUname, error) {
 	}, nil
 }
 
+func Statfs(path string) (*SysStatfs, error) {
+	var stfs unix.Statfs_t
+	if err := unix.Statfs(path, &stfs); err != nil {
+		return &SysStatfs{}, err
+	}
+	bsize := uint64(stfs.Bsize)
+	return &SysStatfs{
+		SizeBytes:  stfs.Blocks * bsize,
+		FreeBytes:  stfs.Bfree * bsize,
+		AvailBytes: stfs.Bavail * bsize,
+	}, nil
+}
+
 func unameBytesToString(b [65]byte) string {
 	n := bytes.IndexByte(b[:], 0)
 	if n < 0 {
@@ -5,6 +5,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -16,66 +22,174 @@ import (
 // vendor-specific tools.
 type storageDeviceInfo struct {
 	BlkdevInfo
-	SsaLogicalDrive *SsaLogicalDriveInfo
+	Controller   *RaidController
+	LogicalDrive *RaidLogicalDrive
+}
+
+// ssaSmartInfo associates SMART data, as reported via smartctl, with physical
+// drive behind Smart Array controller.
+type ssaSmartInfo struct {
+	Controller    *RaidController
+	LogicalDrive  *RaidLogicalDrive
+	PhysicalDrive *RaidPhysicalDrive
+	Smart         *SmartDriveInfo
 }
 
 // storageDevicesProbe is an auxiliary object to collect storage-devices info
-// from system and externa-tools.
+// from system and externa-tools. Its context bounds the lifetime of external
+// tools' processes and API requests.
 type storageDevicesProbe struct {
+	ctx    context.Context
 	log    logr.Logger
 	ident  *Ident
 	procfs *ProcFS
 	sysfs  *SysFS
+	devfs  *DevFS
+	udevfs *UdevFS
+	opts   *Options
 	clnt   *client
-	hasSSA bool
+	rball  []RaidBackend
+	rbes   []RaidBackend
+	rbvers map[string]string
+	raid   raidSnapshot
+	smart  ssaSmartSnapshot
+	pvs    pvSnapshot
+}
+
+// raidSnapshot is the result of the latest probe of RAID backends. Callers
+// within raidSnapshotMaxAge of each other (e.g. the collectors of a single
+// scrape, node reporter and inventory) share a single run of vendor tools;
+// concurrent callers wait for the probe in flight. Each backend's own error
+// is kept in errs, keyed by backend name.
+type raidSnapshot struct {
+	mtx   sync.Mutex
+	when  time.Time
+	ctrls []RaidController
+	errs  map[string]error
+	err   error
+}
+
+const raidSnapshotMaxAge = 15 * time.Second
+
+// ssaSmartSnapshot is the last SMART probe of Smart Array physical drives,
+// shared (as raidSnapshot) by concurrent scrapes within raidSnapshotMaxAge.
+type ssaSmartSnapshot struct {
+	mtx   sync.Mutex
+	when  time.Time
+	smart []ssaSmartInfo
+	err   error
 }
 
-func newStorageDevicesProbe(log logr.Logger) *storageDevicesProbe {
+func newStorageDevicesProbe(ctx context.Context, log logr.Logger,
+	opts *Options) *storageDevicesProbe {
 	return &storageDevicesProbe{
+		ctx:    ctx,
 		log:    log,
 		ident:  SelfIdent(),
 		procfs: NewProcFS(),
-		sysfs:  NewSysFS(),
-		hasSSA: true,
+		sysfs:  NewSysFSAt(filepath.Join(opts.HostRoot, sysDefaultMountPoint)),
+		devfs:  NewDevFSAt(filepath.Join(opts.HostRoot, devDefaultMountPoint)),
+		udevfs: NewUdevFS(opts.HostRoot),
+		opts:   opts,
+		rball:  listRaidBackends(opts),
+		rbes:   []RaidBackend{},
+		rbvers: map[string]string{},
 	}
 }
 
 func (sdp *storageDevicesProbe) init() error {
-	if err := sdp.initClient(); err != nil {
-		return err
-	}
-	if err := sdp.initSelf(); err != nil {
+	props, err := ParseUdevProperties(sdp.opts.UdevProperties)
+	if err != nil {
 		return err
 	}
-	if err := sdp.initXtool(); err != nil {
+	sdp.opts.UdevProperties = props
+	if err := sdp.initKube(); err != nil {
 		return err
 	}
+	sdp.initBackends()
 	return nil
 }
 
-func (sdp *storageDevicesProbe) initXtool() error {
-	loc, err := LocateSsa()
-	if err != nil {
-		sdp.hasSSA = false
-		sdp.log.Info("unable to find ssacli tool")
-		return nil // OK
+// initKube initializes the (optional) Kubernetes integration, according to
+// the configured mode. Without it, the exporter runs in standalone mode (e.g.
+// as systemd service), identified by host's name.
+func (sdp *storageDevicesProbe) initKube() error {
+	switch sdp.opts.Kubernetes {
+	case KubernetesOff:
+		sdp.log.Info("kubernetes integration disabled",
+			"hostname", sdp.ident.Hostname)
+		return nil
+	case KubernetesOn:
+		return sdp.initKubeSelf()
+	case KubernetesAuto:
+		if err := sdp.initKubeSelf(); err != nil {
+			sdp.log.Info("kubernetes unavailable, running standalone",
+				"reason", err.Error(), "hostname", sdp.ident.Hostname)
+			sdp.clnt = nil
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown kubernetes mode: %q", sdp.opts.Kubernetes)
 	}
-	sdp.log.Info("found ssacli tool at: " + loc)
-	vers, err := RunSsaVersion()
-	if err != nil {
-		sdp.hasSSA = false
-		sdp.log.Error(err, "failed to run ssacli tool")
+}
+
+func (sdp *storageDevicesProbe) initKubeSelf() error {
+	if err := sdp.initClient(); err != nil {
 		return err
 	}
-	sdp.log.Info("ssacli", "version", vers)
-	return nil
+	return sdp.initSelf()
+}
+
+// hasKube returns true if Kubernetes integration is active
+func (sdp *storageDevicesProbe) hasKube() bool {
+	return sdp.clnt != nil
+}
+
+// initBackends detects which of the known RAID backends are usable on local
+// host. A backend which fails to report its version is ignored, as well as a
+// backend of the same vendor as already detected one (e.g. Redfish when
+// ssacli is installed on host), to avoid reporting controllers twice. The
+// version of each detected backend is kept, so that it is not re-queried on
+// each scrape.
+func (sdp *storageDevicesProbe) initBackends() {
+	vendors := map[string]bool{}
+	for _, rbe := range sdp.rball {
+		if vendors[rbe.Vendor()] {
+			continue
+		}
+		if err := rbe.Detect(); err != nil {
+			sdp.log.Info("RAID backend not detected", "backend", rbe.Name())
+			continue
+		}
+		vers, err := rbe.Version(sdp.ctx)
+		if err != nil {
+			sdp.log.Error(err, "failed to run RAID backend", "backend", rbe.Name())
+			continue
+		}
+		sdp.log.Info("RAID backend", "backend", rbe.Name(), "version", vers)
+		sdp.rbes = append(sdp.rbes, rbe)
+		sdp.rbvers[rbe.Name()] = vers
+		vendors[rbe.Vendor()] = true
+	}
+}
+
+func (sdp *storageDevicesProbe) hasRaidBackends() bool {
+	return len(sdp.rbes) > 0
+}
+
+func (sdp *storageDevicesProbe) hasRaidBackend(name string) bool {
+	for _, rbe := range sdp.rbes {
+		if rbe.Name() == name {
+			return true
+		}
+	}
+	return false
 }
 
 func (sdp *storageDevicesProbe) initClient() error {
 	kclnt, err := newClient()
 	if err != nil {
-		sdp.log.Error(err, "failed to create clientset")
-		return err
+		return fmt.Errorf("failed to create clientset: %w", err)
 	}
 	sdp.clnt = kclnt
 	return nil
@@ -84,7 +198,7 @@ func (sdp *storageDevicesProbe) initClient() error {
 func (sdp *storageDevicesProbe) initSelf() error {
 	ident := SelfIdent()
 	if ident.HostIP == "" {
-		return fmt.Errorf("unable to resolve hostip: %+v", ident)
+		return fmt.Errorf("unable to resolve hostip: %s not set", HostIPEnvKey)
 	}
 	pod, err := sdp.discoverSelfPod()
 	if err != nil {
@@ -100,23 +214,20 @@ func (sdp *storageDevicesProbe) probeDevices() ([]storageDeviceInfo, error) {
 	if err != nil {
 		return []storageDeviceInfo{}, err
 	}
-	ssm, err := sdp.probeSsaLogicalDevices()
+	ctrls, err := sdp.probeRaidControllers()
 	if err != nil {
 		return []storageDeviceInfo{}, err
 	}
-	return newStorageDeviceInfo(bdi, ssm), nil
+	return newStorageDeviceInfo(bdi, ctrls), nil
 }
 
-func newStorageDeviceInfo(bdis []BlkdevInfo, ssm *SsaMap) []storageDeviceInfo {
+func newStorageDeviceInfo(bdis []BlkdevInfo, ctrls []RaidController) []storageDeviceInfo {
 	ret := []storageDeviceInfo{}
-	for _, bdi := range bdis {
+	for i := range bdis {
 		sdi := storageDeviceInfo{
-			BlkdevInfo: bdi,
-		}
-		ssaent, ok := ssm.DevMap[bdi.Name]
-		if ok {
-			sdi.SsaLogicalDrive = &ssaent
+			BlkdevInfo: bdis[i],
 		}
+		sdi.Controller, sdi.LogicalDrive = LookupRaidLogicalDrive(ctrls, &bdis[i])
 		ret = append(ret, sdi)
 	}
 	return ret
@@ -125,62 +236,218 @@ func newStorageDeviceInfo(bdis []BlkdevInfo, ssm *SsaMap) []storageDeviceInfo {
 func (sdp *storageDevicesProbe) probeBlockDevices() ([]BlkdevInfo, error) {
 	bdi, err := DiscoverBlkdevInfo(sdp.procfs, sdp.sysfs)
 	if err != nil {
-		sdp.log.Error(err, "failed to discover block devices")
-		return []BlkdevInfo{}, err
+		return []BlkdevInfo{}, fmt.Errorf("failed to discover block devices: %w", err)
 	}
-	return bdi, nil
+	bdi = ResolveBlkdevLinks(sdp.devfs, bdi)
+	return ResolveBlkdevUdev(sdp.udevfs, bdi), nil
 }
 
 func (sdp *storageDevicesProbe) probeBlockDevicesIO() ([]BlkdevIOInfo, error) {
 	ret, err := DescoveBlockDevicesIO(sdp.procfs, sdp.sysfs)
 	if err != nil {
-		sdp.log.Error(err, "failed to discover block devices IO stats")
-		return []BlkdevIOInfo{}, err
+		return []BlkdevIOInfo{}, fmt.Errorf("failed to discover block devices IO stats: %w", err)
 	}
 	return ret, nil
 }
 
-func (sdp *storageDevicesProbe) probeSsaLogicalDevices() (*SsaMap, error) {
-	ssm := NewSsaMap()
-	if _, err := LocateSsa(); err != nil {
-		return ssm, nil // OK -- run without ssacli
+func (sdp *storageDevicesProbe) probeBlockDevicesMounts() ([]BlkdevMountInfo, error) {
+	mounts, err := sdp.hostMountInfo()
+	if err != nil {
+		return []BlkdevMountInfo{}, fmt.Errorf("failed to read mountinfo: %w", err)
 	}
-	if _, err := RunSsaVersion(); err != nil {
-		return ssm, err
+	return DiscoverBlkdevMounts(sdp.sysfs, mounts, sdp.hostRootFS())
+}
+
+// hostMountInfo returns the mount table as seen by the host: when running with
+// host's pseudo file-systems mounted at non-default location, use the mount
+// namespace of host's init process.
+func (sdp *storageDevicesProbe) hostMountInfo() ([]MountInfo, error) {
+	if filepath.Clean(sdp.opts.HostRoot) == DefaultHostRoot {
+		return sdp.procfs.MountInfo("self")
 	}
-	cfg, err := RunSsaShowConfig()
-	if err != nil {
-		sdp.log.Error(err, "failed to run ssacli show config")
-		return nil, err
+	hostProcfs := NewProcFSAt(filepath.Join(sdp.opts.HostRoot, procDefaultMountPoint))
+	return hostProcfs.MountInfo("1")
+}
+
+// hostRootFS returns the path of host's root directory: when running with
+// host's pseudo file-systems mounted at non-default location, host's root is
+// reached via the root of host's init process, so that no more of host's
+// file-systems than /sys, /dev, /proc and /run/udev need to be mounted.
+func (sdp *storageDevicesProbe) hostRootFS() string {
+	if filepath.Clean(sdp.opts.HostRoot) == DefaultHostRoot {
+		return DefaultHostRoot
 	}
+	return filepath.Join(sdp.opts.HostRoot, procDefaultMountPoint, "1", "root")
+}
 
-	ldm, err := ParseConfigToLogical(cfg)
+func (sdp *storageDevicesProbe) probeNvmeControllers() ([]NvmeControllerInfo, error) {
+	ret, err := sdp.sysfs.NvmeControllers()
 	if err != nil {
-		sdp.log.Error(err, "failed to parse ssacli show config output")
-		return nil, err
+		return []NvmeControllerInfo{}, fmt.Errorf("failed to discover NVMe controllers: %w", err)
 	}
+	return ret, nil
+}
 
-	ssm, err = sdp.filterDevices(ldm)
+func (sdp *storageDevicesProbe) probeLoadAvg() (*SysLoadAvg, error) {
+	ret, err := sdp.procfs.LoadAvg()
 	if err != nil {
-		sdp.log.Error(err, "failed to filter logical devices")
-		return nil, err
+		return nil, fmt.Errorf("failed to read loadavg: %w", err)
+	}
+	return ret, nil
+}
+
+func (sdp *storageDevicesProbe) probePressureIO() (*SysPressure, error) {
+	ret, err := sdp.procfs.PressureIO()
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.EOPNOTSUPP) {
+		return nil, nil // OK -- kernel without PSI support
+	}
+	return ret, err
+}
+
+func (sdp *storageDevicesProbe) probeCgroupsPressureIO() (map[string]*SysPressure, error) {
+	return sdp.sysfs.CgroupsPressureIO()
+}
+
+// probeRaidControllers returns the current state of RAID controllers, as a
+// snapshot shared by all of its callers; callers must not modify it.
+func (sdp *storageDevicesProbe) probeRaidControllers() ([]RaidController, error) {
+	sdp.raid.mtx.Lock()
+	defer sdp.raid.mtx.Unlock()
+	sdp.refreshRaidSnapshot()
+	return sdp.raid.ctrls, sdp.raid.err
+}
+
+// probeRaidBackendErrors returns the error of each of the detected RAID
+// backends (nil when usable) in the probe of current snapshot.
+func (sdp *storageDevicesProbe) probeRaidBackendErrors() map[string]error {
+	sdp.raid.mtx.Lock()
+	defer sdp.raid.mtx.Unlock()
+	sdp.refreshRaidSnapshot()
+	return sdp.raid.errs
+}
+
+// refreshRaidSnapshot re-probes RAID backends if the snapshot is older than
+// raidSnapshotMaxAge; must be called with sdp.raid.mtx held.
+func (sdp *storageDevicesProbe) refreshRaidSnapshot() {
+	if sdp.raid.when.IsZero() || time.Since(sdp.raid.when) > raidSnapshotMaxAge {
+		sdp.raid.ctrls, sdp.raid.errs = sdp.probeRaidBackends()
+		sdp.raid.err = nil
+		for _, rbe := range sdp.rbes {
+			if err := sdp.raid.errs[rbe.Name()]; err != nil {
+				sdp.raid.err = err
+				break
+			}
+		}
+		sdp.raid.when = time.Now()
+	}
+}
+
+// probeRaidBackends queries each of the detected RAID backends for the
+// current state of its controllers. A failing backend does not hide the
+// controllers of the others; its error is returned keyed by backend name.
+func (sdp *storageDevicesProbe) probeRaidBackends() ([]RaidController, map[string]error) {
+	ret := []RaidController{}
+	errs := map[string]error{}
+	for _, rbe := range sdp.rbes {
+		ctrls, err := rbe.Probe(sdp.ctx)
+		if err != nil {
+			errs[rbe.Name()] = fmt.Errorf("failed to probe RAID backend %s: %w", rbe.Name(), err)
+			continue
+		}
+		for _, ctrl := range ctrls {
+			ctrl.Backend = rbe.Name()
+			ctrl.Vendor = rbe.Vendor()
+			ret = append(ret, ctrl)
+		}
+	}
+	return ret, errs
+}
+
+// probeSsaSmart returns SMART data of physical drives behind Smart Array
+// controllers, as a snapshot shared by all of its callers; callers must not
+// modify it.
+func (sdp *storageDevicesProbe) probeSsaSmart() ([]ssaSmartInfo, error) {
+	sdp.smart.mtx.Lock()
+	defer sdp.smart.mtx.Unlock()
+	if sdp.smart.when.IsZero() || time.Since(sdp.smart.when) > raidSnapshotMaxAge {
+		sdp.smart.smart, sdp.smart.err = sdp.probeSsaSmartDrives()
+		sdp.smart.when = time.Now()
 	}
-	return ssm, nil
+	return sdp.smart.smart, sdp.smart.err
 }
 
-func (sdp *storageDevicesProbe) filterDevices(ldm *SsaMap) (*SsaMap, error) {
-	ssm := NewSsaMap()
-	bdm, err := DiscoverBlkdevStats(sdp.procfs, sdp.sysfs)
+// probeSsaSmartDrives queries SMART data of physical drives behind each Smart
+// Array controller, using smartctl cciss pass-through via one of the
+// controller's logical devices. Drive indices are enumerated until all of the
+// controller's known physical drives are matched by serial number, or until a
+// run of indices without a drive.
+func (sdp *storageDevicesProbe) probeSsaSmartDrives() ([]ssaSmartInfo, error) {
+	ret := []ssaSmartInfo{}
+	if _, err := LocateSmartctl(); err != nil {
+		return ret, err
+	}
+	sdis, err := sdp.probeDevices()
 	if err != nil {
-		sdp.log.Error(err, "failed to discover block devices")
-		return ssm, err
+		return ret, err
 	}
-	for dev, val := range ldm.DevMap {
-		if bdm.IOStats[dev] != nil {
-			ssm.DevMap[dev] = val
+	slots := map[string][]storageDeviceInfo{}
+	for _, sdi := range sdis {
+		if sdi.LogicalDrive != nil && sdi.Controller.Backend == "ssacli" {
+			slot := sdi.Controller.ID
+			slots[slot] = append(slots[slot], sdi)
 		}
 	}
-	return ssm, nil
+	for _, slotsdis := range slots {
+		ret = append(ret, sdp.probeSsaSlotSmart(slotsdis)...)
+	}
+	return ret, nil
+}
+
+func (sdp *storageDevicesProbe) probeSsaSlotSmart(sdis []storageDeviceInfo) []ssaSmartInfo {
+	ret := []ssaSmartInfo{}
+	pending := map[string]ssaSmartInfo{}
+	for _, sdi := range sdis {
+		ldi := sdi.LogicalDrive
+		for i := range ldi.PhysicalDrives {
+			pdi := &ldi.PhysicalDrives[i]
+			serial := strings.TrimSpace(pdi.Serial)
+			if serial != "" {
+				pending[serial] = ssaSmartInfo{
+					Controller:    sdi.Controller,
+					LogicalDrive:  ldi,
+					PhysicalDrive: pdi,
+				}
+			}
+		}
+	}
+	device := filepath.Join(sdp.hostRootFS(), "dev", sdis[0].Name)
+	misses := 0
+	for idx := 0; idx < smartctlMaxCcissIndex && len(pending) > 0; idx++ {
+		smart, err := RunSmartctlCciss(sdp.ctx, device, idx)
+		if err != nil {
+			if sdp.ctx.Err() != nil {
+				break
+			}
+			misses++
+			if misses >= smartctlMaxCcissMisses {
+				break
+			}
+			continue
+		}
+		misses = 0
+		ent, ok := pending[smart.Serial]
+		if !ok {
+			continue
+		}
+		ent.Smart = smart
+		ret = append(ret, ent)
+		delete(pending, smart.Serial)
+	}
+	if len(pending) > 0 {
+		sdp.log.Info("smartctl: unmatched physical drives",
+			"device", device, "count", len(pending))
+	}
+	return ret
 }
 
 func (sdp *storageDevicesProbe) discoverSelfPod() (*corev1.Pod, error) {
@@ -191,10 +458,9 @@ func (sdp *storageDevicesProbe) discoverSelfPod() (*corev1.Pod, error) {
 		Namespace: sdp.ident.Namespace,
 		Name:      sdp.ident.Name,
 	}
-	pod, err := GetRunningPod(context.TODO(), sdp.clnt, nname)
+	pod, err := GetRunningPod(sdp.ctx, sdp.clnt, nname)
 	if err != nil {
-		sdp.log.Error(err, "failed to get self pod")
-		return nil, err
+		return nil, fmt.Errorf("failed to get self pod: %w", err)
 	}
 	return pod, nil
 }
@@ -3,6 +3,7 @@ package devmon
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -15,10 +16,10 @@ const (
 )
 
 func NewProcFS() *ProcFS {
-	return newProcFS(procDefaultMountPoint)
+	return NewProcFSAt(procDefaultMountPoint)
 }
 
-func newProcFS(prefix string) *ProcFS {
+func NewProcFSAt(prefix string) *ProcFS {
 	return &ProcFS{
 		PseudoFS: PseudoFS{
 			Prefix: prefix,
@@ -75,6 +76,12 @@ func (procfs *ProcFS) parseLoadAvg(vals []string) (*SysLoadAvg, error) {
 	}, nil
 }
 
+// PressureIO parses system-wide I/O pressure stall information from
+// "/proc/pressure/io". Requires kernel with CONFIG_PSI.
+func (procfs *ProcFS) PressureIO() (*SysPressure, error) {
+	return procfs.ReadFilePressure("pressure", "io")
+}
+
 // DiskStats converts "/proc/diskstats" info into BlkdevIOStat representation.
 // See: https://www.kernel.org/doc/Documentation/ABI/testing/procfs-diskstats
 func (procfs *ProcFS) DiskStats() ([]BlkdevIOInfo, error) {
@@ -204,3 +211,85 @@ func (procfs *ProcFS) parseDevicesLine(line string) (int, string, error) {
 
 	return val, fields[1], nil
 }
+
+// MountInfo parses "/proc/<pid>/mountinfo" of process pid (or "self") into
+// a list of mount entries. See man(5) proc.
+func (procfs *ProcFS) MountInfo(pid string) ([]MountInfo, error) {
+	ret := []MountInfo{}
+	lines, err := procfs.ReadFileLines(pid, "mountinfo")
+	if err != nil {
+		return ret, err
+	}
+	for _, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		mi, err := procfs.parseMountInfoLine(line)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, *mi)
+	}
+	return ret, nil
+}
+
+func (procfs *ProcFS) parseMountInfoLine(line string) (*MountInfo, error) {
+	var err error
+	ret := &MountInfo{}
+	fields, err := procfs.SplitFields(line, 10)
+	if err != nil {
+		return ret, err
+	}
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 || sep+2 >= len(fields) {
+		return ret, fmt.Errorf("bad line in mountinfo: '%s'", line)
+	}
+	if ret.MountID, err = procfs.ParseUint32(fields[0]); err != nil {
+		return ret, err
+	}
+	if ret.ParentID, err = procfs.ParseUint32(fields[1]); err != nil {
+		return ret, err
+	}
+	nums := strings.Split(fields[2], ":")
+	if len(nums) != 2 {
+		return ret, fmt.Errorf("bad device number in mountinfo: '%s'", fields[2])
+	}
+	if ret.Major, err = procfs.ParseUint32(nums[0]); err != nil {
+		return ret, err
+	}
+	if ret.Minor, err = procfs.ParseUint32(nums[1]); err != nil {
+		return ret, err
+	}
+	ret.Root = unescapeMountPath(fields[3])
+	ret.MountPoint = unescapeMountPath(fields[4])
+	ret.Options = fields[5]
+	ret.FSType = fields[sep+1]
+	ret.Source = unescapeMountPath(fields[sep+2])
+	return ret, nil
+}
+
+// unescapeMountPath decodes octal escapes of white-spaces and backslash
+// (e.g. "\040" for space), as used by the kernel in mount paths.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if val, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(val))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -29,3 +29,55 @@ func TestProcfsDiskStats(t *testing.T) {
 	}
 	assert.Greater(t, readIOs, uint64(0))
 }
+
+func TestProcfsMountInfo(t *testing.T) {
+	procfs := devmon.NewProcFS()
+	mounts, err := procfs.MountInfo("self")
+	assert.NoError(t, err)
+	assert.Greater(t, len(mounts), 0)
+	hasRoot := false
+	for _, mi := range mounts {
+		assert.Greater(t, len(mi.MountPoint), 0)
+		assert.Greater(t, len(mi.FSType), 0)
+		if mi.MountPoint == "/" {
+			hasRoot = true
+		}
+	}
+	assert.True(t, hasRoot)
+}
+
+func TestProcfsPressureIO(t *testing.T) {
+	procfs := devmon.NewProcFS()
+	psi, err := procfs.PressureIO()
+	if err != nil {
+		t.Skip("no pressure stall information")
+	}
+	assert.NotNil(t, psi)
+	assert.GreaterOrEqual(t, psi.Some.Avg10, 0.0)
+	if psi.Full != nil {
+		assert.GreaterOrEqual(t, psi.Some.TotalUSec, psi.Full.TotalUSec)
+	}
+}
+
+func TestReadFilePressure(t *testing.T) {
+	pfs := devmon.NewProcFSAt("testdata/pressure")
+	psi, err := pfs.ReadFilePressure("io")
+	assert.NoError(t, err)
+	assert.Equal(t, devmon.SysPressureStat{
+		Avg10: 0.52, Avg60: 1.37, Avg300: 0.88, TotalUSec: 183726451,
+	}, psi.Some)
+	assert.Equal(t, &devmon.SysPressureStat{
+		Avg10: 0.31, Avg60: 1.02, Avg300: 0.65, TotalUSec: 151093322,
+	}, psi.Full)
+
+	psi, err = pfs.ReadFilePressure("cpu")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.14, psi.Some.Avg10)
+	assert.Equal(t, uint64(2937405873), psi.Some.TotalUSec)
+	assert.Nil(t, psi.Full)
+
+	_, err = pfs.ReadFilePressure("bad")
+	assert.Error(t, err)
+	_, err = pfs.ReadFilePressure("nonexistent")
+	assert.Error(t, err)
+}
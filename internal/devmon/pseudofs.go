@@ -158,6 +158,60 @@ func (pfs *PseudoFS) SplitFields(s string, min int) ([]string, error) {
 	return fields, nil
 }
 
+// ReadFilePressure parses PSI file (e.g. /proc/pressure/io or cgroup's
+// io.pressure) with "some" and (optional) "full" lines.
+func (pfs *PseudoFS) ReadFilePressure(subs ...string) (*SysPressure, error) {
+	lines, err := pfs.ReadFileLines(subs...)
+	if err != nil {
+		return nil, err
+	}
+	ret := &SysPressure{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var stat *SysPressureStat
+		switch fields[0] {
+		case "some":
+			stat = &ret.Some
+		case "full":
+			ret.Full = &SysPressureStat{}
+			stat = ret.Full
+		default:
+			return nil, fmt.Errorf("bad line in pressure file: '%s'", line)
+		}
+		if err := pfs.parsePressureFields(fields[1:], stat); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (pfs *PseudoFS) parsePressureFields(fields []string, stat *SysPressureStat) error {
+	var err error
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("bad pressure field: '%s'", field)
+		}
+		switch kv[0] {
+		case "avg10":
+			stat.Avg10, err = pfs.ParseFloat(kv[1])
+		case "avg60":
+			stat.Avg60, err = pfs.ParseFloat(kv[1])
+		case "avg300":
+			stat.Avg300, err = pfs.ParseFloat(kv[1])
+		case "total":
+			stat.TotalUSec, err = pfs.ParseUint64(kv[1])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (pfs *PseudoFS) IsDir(subs ...string) (bool, error) {
 	fi, err := os.Stat(pfs.resolvePath(subs))
 	if err != nil {
@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// maxSymlinkHops bounds symbolic links resolution, as in Linux's
+	// path_lookup (MAXSYMLINKS)
+	maxSymlinkHops = 40
+
+	// pvSnapshotMaxAge bounds the staleness of the local Node and of the
+	// cluster's PersistentVolumes, as listed from the API server
+	pvSnapshotMaxAge = 2 * time.Minute
+)
+
+// pvBackingDeviceInfo associates a local PersistentVolume on this node with
+// its underlying block device and, if any, RAID controller and logical drive.
+type pvBackingDeviceInfo struct {
+	PersistentVolume *corev1.PersistentVolume
+	Device           string
+	Controller       *RaidController
+	LogicalDrive     *RaidLogicalDrive
+}
+
+// pvSnapshot caches the local Node and the cluster's PersistentVolumes, so
+// that metrics scrapes do not issue a cluster-wide list each; failures are
+// not cached.
+type pvSnapshot struct {
+	mtx  sync.Mutex
+	when time.Time
+	node *corev1.Node
+	pvs  []corev1.PersistentVolume
+}
+
+func (pvs *pvSnapshot) get(ctx context.Context, cset kubernetes.Interface,
+	nodename string, now time.Time) (*corev1.Node, []corev1.PersistentVolume, error) {
+	pvs.mtx.Lock()
+	defer pvs.mtx.Unlock()
+	if !pvs.when.IsZero() && now.Sub(pvs.when) <= pvSnapshotMaxAge {
+		return pvs.node, pvs.pvs, nil
+	}
+	node, err := cset.CoreV1().Nodes().Get(ctx, nodename, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	pvl, err := cset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+	pvs.when = now
+	pvs.node = node
+	pvs.pvs = pvl.Items
+	return pvs.node, pvs.pvs, nil
+}
+
+// PersistentVolumeLocalPath returns the host path of a local (or hostPath)
+// PersistentVolume.
+func PersistentVolumeLocalPath(pv *corev1.PersistentVolume) (string, bool) {
+	switch {
+	case pv.Spec.Local != nil:
+		return pv.Spec.Local.Path, true
+	case pv.Spec.HostPath != nil:
+		return pv.Spec.HostPath.Path, true
+	default:
+		return "", false
+	}
+}
+
+// PersistentVolumeOnNode returns true if the PersistentVolume's required node
+// affinity selects node. Node selector terms are ORed, while their
+// requirements are ANDed; Gt and Lt operators are not supported.
+func PersistentVolumeOnNode(pv *corev1.PersistentVolume, node *corev1.Node) bool {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return false
+	}
+	fields := map[string]string{"metadata.name": node.Name}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		if nodeSelectorMatch(term.MatchExpressions, node.Labels) &&
+			nodeSelectorMatch(term.MatchFields, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeSelectorMatch(reqs []corev1.NodeSelectorRequirement, kvs map[string]string) bool {
+	for _, req := range reqs {
+		val, exists := kvs[req.Key]
+		switch req.Operator {
+		case corev1.NodeSelectorOpIn:
+			if !exists || !containsString(req.Values, val) {
+				return false
+			}
+		case corev1.NodeSelectorOpNotIn:
+			if exists && containsString(req.Values, val) {
+				return false
+			}
+		case corev1.NodeSelectorOpExists:
+			if !exists {
+				return false
+			}
+		case corev1.NodeSelectorOpDoesNotExist:
+			if exists {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(ss []string, s string) bool {
+	for _, si := range ss {
+		if si == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveHostPath resolves the symbolic links of a host path, relative to
+// host's root file-system mounted at root: absolute link targets (e.g. of
+// local-storage operator's links into /dev/disk/by-id/) are resolved within
+// root as well. Only the links of the path's final component are followed.
+func ResolveHostPath(root, path string) (string, error) {
+	cur := filepath.Clean(path)
+	for i := 0; i < maxSymlinkHops; i++ {
+		fi, err := os.Lstat(filepath.Join(root, cur))
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return cur, nil
+		}
+		target, err := os.Readlink(filepath.Join(root, cur))
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(cur), target)
+		}
+		cur = filepath.Clean(target)
+	}
+	return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+}
+
+// hostPathDevice returns the device number of a block device at host path,
+// or of the device which holds the file-system of any other file (e.g. of a
+// file-system mode local volume's directory).
+func hostPathDevice(root, path string) (uint32, uint32, error) {
+	hpath, err := ResolveHostPath(root, path)
+	if err != nil {
+		return 0, 0, err
+	}
+	fi, err := os.Stat(filepath.Join(root, hpath))
+	if err != nil {
+		return 0, 0, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, errors.New("failed to stat " + hpath)
+	}
+	dev := st.Dev
+	if fi.Mode()&os.ModeDevice != 0 {
+		dev = st.Rdev
+	}
+	return unix.Major(uint64(dev)), unix.Minor(uint64(dev)), nil
+}
+
+// probePersistentVolumes maps the local PersistentVolumes of this node onto
+// their block devices and RAID logical drives.
+func (sdp *storageDevicesProbe) probePersistentVolumes() ([]pvBackingDeviceInfo, error) {
+	ret := []pvBackingDeviceInfo{}
+	if !sdp.hasKube() {
+		return ret, nil
+	}
+	node, pvs, err := sdp.pvs.get(sdp.ctx, sdp.clnt.ClientSet,
+		sdp.ident.Nodename, time.Now())
+	if err != nil {
+		return ret, err
+	}
+	sdis, err := sdp.probeDevices()
+	if err != nil {
+		return ret, err
+	}
+	for i := range pvs {
+		pv := &pvs[i]
+		path, ok := PersistentVolumeLocalPath(pv)
+		if !ok || !PersistentVolumeOnNode(pv, node) {
+			continue
+		}
+		major, minor, err := hostPathDevice(sdp.hostRootFS(), path)
+		if err != nil {
+			sdp.log.Info("failed to resolve persistent volume path",
+				"pv", pv.Name, "path", path, "err", err.Error())
+			continue
+		}
+		name, err := sdp.sysfs.BlockDeviceOf(major, minor)
+		if err != nil {
+			continue
+		}
+		pvi := pvBackingDeviceInfo{
+			PersistentVolume: pv,
+			Device:           filepath.Join("/dev", name),
+		}
+		for j := range sdis {
+			if sdis[j].Name == name {
+				pvi.Controller = sdis[j].Controller
+				pvi.LogicalDrive = sdis[j].LogicalDrive
+			}
+		}
+		ret = append(ret, pvi)
+	}
+	return ret, nil
+}
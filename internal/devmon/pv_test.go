@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestLocalPV(path string, terms ...corev1.NodeSelectorTerm) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-pv-1"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				Local: &corev1.LocalVolumeSource{Path: path},
+			},
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{NodeSelectorTerms: terms},
+			},
+		},
+	}
+}
+
+func TestPersistentVolumeOnNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "worker-0",
+			Labels: map[string]string{
+				"kubernetes.io/hostname": "worker-0",
+			},
+		},
+	}
+	hostnameIn := func(vals ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{
+				{
+					Key:      "kubernetes.io/hostname",
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   vals,
+				},
+			},
+		}
+	}
+	path := "/dev/disk/by-id/wwn-0x600508b1001c2c3d"
+
+	pv := newTestLocalPV(path, hostnameIn("worker-0"))
+	assert.True(t, devmon.PersistentVolumeOnNode(pv, node))
+	lpath, ok := devmon.PersistentVolumeLocalPath(pv)
+	assert.True(t, ok)
+	assert.Equal(t, path, lpath)
+
+	pv = newTestLocalPV(path, hostnameIn("worker-1"))
+	assert.False(t, devmon.PersistentVolumeOnNode(pv, node))
+
+	pv = newTestLocalPV(path, hostnameIn("worker-1"), hostnameIn("worker-0", "worker-2"))
+	assert.True(t, devmon.PersistentVolumeOnNode(pv, node))
+
+	pv = newTestLocalPV(path, corev1.NodeSelectorTerm{
+		MatchFields: []corev1.NodeSelectorRequirement{
+			{
+				Key:      "metadata.name",
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{"worker-0"},
+			},
+		},
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{
+				Key:      "node-role.kubernetes.io/master",
+				Operator: corev1.NodeSelectorOpDoesNotExist,
+			},
+		},
+	})
+	assert.True(t, devmon.PersistentVolumeOnNode(pv, node))
+
+	pv = newTestLocalPV(path)
+	assert.False(t, devmon.PersistentVolumeOnNode(pv, node))
+
+	pv.Spec.NodeAffinity = nil
+	assert.False(t, devmon.PersistentVolumeOnNode(pv, node))
+
+	pv.Spec.Local = nil
+	_, ok = devmon.PersistentVolumeLocalPath(pv)
+	assert.False(t, ok)
+}
+
+func TestResolveHostPath(t *testing.T) {
+	root := t.TempDir()
+	byid := filepath.Join(root, "dev", "disk", "by-id")
+	assert.NoError(t, os.MkdirAll(byid, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "dev", "sdb"), []byte{}, 0600))
+	assert.NoError(t, os.Symlink("../../sdb", filepath.Join(byid, "wwn-0x600508b1001c2c3d")))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "mnt", "local-storage"), 0755))
+	assert.NoError(t, os.Symlink("/dev/disk/by-id/wwn-0x600508b1001c2c3d",
+		filepath.Join(root, "mnt", "local-storage", "local-pv-1")))
+
+	path, err := devmon.ResolveHostPath(root, "/mnt/local-storage/local-pv-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/sdb", path)
+
+	path, err = devmon.ResolveHostPath(root, "/dev/sdb")
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/sdb", path)
+
+	_, err = devmon.ResolveHostPath(root, "/dev/sdc")
+	assert.Error(t, err)
+
+	assert.NoError(t, os.Symlink("loop", filepath.Join(root, "dev", "loop")))
+	_, err = devmon.ResolveHostPath(root, "/dev/loop")
+	assert.Error(t, err)
+}
+
+func TestPVSnapshot(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}}
+	pv := newTestLocalPV("/mnt/local-storage/localblock/sdb")
+	cset := fake.NewSimpleClientset(node, pv)
+	ctx := context.Background()
+	now := time.Date(2022, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	pvs := &devmon.PVSnapshot{}
+	snode, spvs, err := pvs.Get(ctx, cset, "worker-0", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "worker-0", snode.Name)
+	assert.Equal(t, 1, len(spvs))
+	assert.Equal(t, 2, len(cset.Actions()))
+
+	// within max age, served from snapshot
+	_, spvs, err = pvs.Get(ctx, cset, "worker-0", now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(spvs))
+	assert.Equal(t, 2, len(cset.Actions()))
+
+	// once stale, re-fetched from API server
+	_, _, err = pvs.Get(ctx, cset, "worker-0", now.Add(3*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(cset.Actions()))
+
+	// failures are not cached
+	pvs2 := &devmon.PVSnapshot{}
+	_, _, err = pvs2.Get(ctx, cset, "worker-1", now)
+	assert.Error(t, err)
+	_, _, err = pvs2.Get(ctx, cset, "worker-1", now)
+	assert.Error(t, err)
+	assert.Equal(t, 6, len(cset.Actions()))
+}
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	blkdevMatchNone = iota
+	blkdevMatchByName
+	blkdevMatchByID
+)
+
+// RaidPhysicalDrive represents a vendor-neutral view of physical drive behind
+// RAID controller.
+type RaidPhysicalDrive struct {
+	ID             string  `json:"id"`
+	Box            string  `json:"box"`
+	Bay            string  `json:"bay"`
+	Model          string  `json:"model"`
+	Serial         string  `json:"serial"`
+	Firmware       string  `json:"firmware"`
+	MediaType      string  `json:"mediatype"`
+	Interface      string  `json:"interface"`
+	SizeBytes      uint64  `json:"sizebytes"`
+	Status         string  `json:"status"`
+	UniqueID       string  `json:"uniqueid"`
+	TempCurr       int64   `json:"tempcurr"`
+	TempMaxi       int64   `json:"tempmaxi"`
+	PowerHours     int64   `json:"powerhours"` // nolint:misspell
+	UsageRemaining float64 `json:"usageremaining"`
+}
+
+// RaidLogicalDrive represents a vendor-neutral view of logical drive (volume)
+// exposed by RAID controller, together with its underlying physical drives.
+type RaidLogicalDrive struct {
+	ID             string              `json:"id"`
+	DiskName       string              `json:"diskname"`
+	SizeBytes      uint64              `json:"sizebytes"`
+	Status         string              `json:"status"`
+	UniqueID       string              `json:"uniqueid"`
+	ArrayName      string              `json:"arrayname"`
+	RaidLevel      string              `json:"raidlevel"`
+	PhysicalDrives []RaidPhysicalDrive `json:"physicaldrives"`
+}
+
+// RaidArray represents a vendor-neutral view of array (drive group) of RAID
+// controller.
+type RaidArray struct {
+	Name           string              `json:"name"`
+	Status         string              `json:"status"`
+	LogicalDrives  []RaidLogicalDrive  `json:"logicaldrives"`
+	PhysicalDrives []RaidPhysicalDrive `json:"physicaldrives"`
+}
+
+// RaidController represents a vendor-neutral view of RAID controller, as
+// reported by one of the RAID backends.
+type RaidController struct {
+	Backend       string      `json:"backend"`
+	Vendor        string      `json:"vendor"`
+	ID            string      `json:"id"`
+	Model         string      `json:"model"`
+	Serial        string      `json:"serial"`
+	Firmware      string      `json:"firmware"`
+	Status        string      `json:"status"`
+	CacheStatus   string      `json:"cachestatus"`
+	BatteryStatus string      `json:"batterystatus"`
+	Temperature   int64       `json:"temperature"`
+	Arrays        []RaidArray `json:"arrays"`
+}
+
+// RaidBackend represents a vendor-specific source of RAID controllers info,
+// typically a command-line management tool installed on local host.
+type RaidBackend interface {
+	// Name returns the backend's name (e.g. "ssacli")
+	Name() string
+
+	// Vendor returns the controllers vendor name (e.g. "hpe")
+	Vendor() string
+
+	// Detect returns nil error if the backend is usable on local host
+	Detect() error
+
+	// Version returns the version string of backend's tool
+	Version(ctx context.Context) (string, error)
+
+	// Probe queries the current state of RAID controllers
+	Probe(ctx context.Context) ([]RaidController, error)
+}
+
+// raidLogicalDriveRef refers to a logical drive within its controller
+type raidLogicalDriveRef struct {
+	Controller   *RaidController
+	LogicalDrive *RaidLogicalDrive
+}
+
+// listRaidBackends returns all known RAID backends, in order of preference
+func listRaidBackends(opts *Options) []RaidBackend {
+	return []RaidBackend{
+		newSsaBackend(),
+		newRedfishBackend(opts.RedfishSecretDir),
+		newStorcliBackend(),
+		newArcconfBackend(),
+	}
+}
+
+// LookupRaidLogicalDrive finds the logical drive which is exposed to the
+// system as block device bdi. Drives are matched by their unique identifier
+// against the device's WWN (or its wwn-* by-id links), which is stable across
+// reboots; matching by disk name is used only when either side lacks an
+// identifier.
+func LookupRaidLogicalDrive(ctrls []RaidController,
+	bdi *BlkdevInfo) (*RaidController, *RaidLogicalDrive) {
+	refs := []raidLogicalDriveRef{}
+	for i := range ctrls {
+		ctrl := &ctrls[i]
+		for j := range ctrl.Arrays {
+			arr := &ctrl.Arrays[j]
+			for k := range arr.LogicalDrives {
+				refs = append(refs, raidLogicalDriveRef{
+					Controller:   ctrl,
+					LogicalDrive: &arr.LogicalDrives[k],
+				})
+			}
+		}
+	}
+	for _, matchKind := range []int{blkdevMatchByID, blkdevMatchByName} {
+		for _, ref := range refs {
+			ld := ref.LogicalDrive
+			if matchBlkdev(bdi, ld.UniqueID, ld.DiskName) == matchKind {
+				return ref.Controller, ld
+			}
+		}
+	}
+	return nil, nil
+}
+
+// matchBlkdev checks whether a logical drive, given by its unique identifier
+// and disk name, is exposed as block device bdi. A match by disk name is
+// rejected when both sides have identifiers which differ.
+func matchBlkdev(bdi *BlkdevInfo, uniqueID, diskName string) int {
+	wwns := blkdevWWNs(bdi)
+	uid := NormalizeWWN(uniqueID)
+	if uid != "" && wwns[uid] {
+		return blkdevMatchByID
+	}
+	if diskName == "" || path.Base(diskName) != bdi.Name {
+		return blkdevMatchNone
+	}
+	if uid != "" && len(wwns) > 0 {
+		return blkdevMatchNone
+	}
+	return blkdevMatchByName
+}
+
+func blkdevWWNs(bdi *BlkdevInfo) map[string]bool {
+	ret := map[string]bool{}
+	if bdi.WWN != "" {
+		ret[bdi.WWN] = true
+	}
+	for _, id := range bdi.ByID {
+		if strings.HasPrefix(id, "wwn-") {
+			if wwn := NormalizeWWN(id); wwn != "" {
+				ret[wwn] = true
+			}
+		}
+	}
+	return ret
+}
+
+// raidArrayStatus derives array status from the status of its logical drives
+func raidArrayStatus(arr *RaidArray) string {
+	for _, ld := range arr.LogicalDrives {
+		if ld.Status != "OK" {
+			return ld.Status
+		}
+	}
+	return "OK"
+}
+
+// sortRaidKeys sorts controller-ids or array names in numeric order (when
+// possible) or lexicographic order
+func sortRaidKeys(keys []string) []string {
+	sort.Slice(keys, func(i, j int) bool {
+		ki, erri := strconv.Atoi(keys[i])
+		kj, errj := strconv.Atoi(keys[j])
+		if erri == nil && errj == nil {
+			return ki < kj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupRaidLogicalDrive(t *testing.T) {
+	cfg, err := devmon.ParseSsaShowConfig(ssacliCtrlAllShowConfigDetail2)
+	assert.NoError(t, err)
+	ctrls := devmon.ParseSsaRaidControllers(cfg)
+
+	bdi := devmon.BlkdevInfo{Name: "sde", WWN: "600508b1001c32b269eb8948f3e5a8e4"}
+	ctrl, ld := devmon.LookupRaidLogicalDrive(ctrls, &bdi)
+	assert.NotNil(t, ctrl)
+	assert.NotNil(t, ld)
+	assert.Equal(t, ctrl.ID, "0")
+	assert.Equal(t, ld.DiskName, "/dev/sdd")
+
+	bdi = devmon.BlkdevInfo{Name: "sde", ByID: []string{"wwn-0x600508b1001c32b269eb8948f3e5a8e4"}}
+	_, ld = devmon.LookupRaidLogicalDrive(ctrls, &bdi)
+	assert.NotNil(t, ld)
+	assert.Equal(t, ld.DiskName, "/dev/sdd")
+
+	bdi = devmon.BlkdevInfo{Name: "sdb"}
+	_, ld = devmon.LookupRaidLogicalDrive(ctrls, &bdi)
+	assert.NotNil(t, ld)
+	assert.Equal(t, ld.UniqueID, "600508B1001CCD7B72DB95E459CDDCCC")
+
+	bdi = devmon.BlkdevInfo{Name: "sdd", WWN: "5000c50094d7beb3"}
+	ctrl, ld = devmon.LookupRaidLogicalDrive(ctrls, &bdi)
+	assert.Nil(t, ctrl)
+	assert.Nil(t, ld)
+}
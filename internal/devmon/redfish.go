@@ -0,0 +1,488 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redfishServiceRootPath = "/redfish/v1/"
+	redfishSystemsPath     = "/redfish/v1/Systems/"
+	redfishRequestTimeout  = 30 * time.Second
+)
+
+// RedfishConfig represents the BMC (iLO) endpoint and credentials, as
+// provided by the keys of a Secret mounted into the exporter's pod.
+type RedfishConfig struct {
+	Endpoint string
+	Username string
+	Password string
+	Insecure bool
+}
+
+type redfishLink struct {
+	ODataID string `json:"@odata.id"`
+}
+
+type redfishCollection struct {
+	Members []redfishLink `json:"Members"`
+}
+
+type redfishStatus struct {
+	Health string `json:"Health"`
+	State  string `json:"State"`
+}
+
+type redfishFirmware struct {
+	Current struct {
+		VersionString string `json:"VersionString"`
+	} `json:"Current"`
+}
+
+type redfishServiceRoot struct {
+	RedfishVersion string      `json:"RedfishVersion"`
+	Systems        redfishLink `json:"Systems"`
+}
+
+type redfishSystemOem struct {
+	Links struct {
+		SmartStorage redfishLink `json:"SmartStorage"`
+	} `json:"Links"`
+}
+
+// redfishSystem is a computer system, with a link to its Smart Storage
+// resource under the vendor-specific (Hpe on iLO 5, Hp on iLO 4) Oem section.
+type redfishSystem struct {
+	Oem struct {
+		Hpe *redfishSystemOem `json:"Hpe"`
+		Hp  *redfishSystemOem `json:"Hp"`
+	} `json:"Oem"`
+}
+
+type redfishSmartStorage struct {
+	Links struct {
+		ArrayControllers redfishLink `json:"ArrayControllers"`
+	} `json:"Links"`
+}
+
+type redfishArrayController struct {
+	ID                string          `json:"Id"`
+	Location          string          `json:"Location"`
+	Model             string          `json:"Model"`
+	SerialNumber      string          `json:"SerialNumber"`
+	FirmwareVersion   redfishFirmware `json:"FirmwareVersion"`
+	Status            redfishStatus   `json:"Status"`
+	CacheModuleStatus *redfishStatus  `json:"CacheModuleStatus"`
+	Links             struct {
+		LogicalDrives redfishLink `json:"LogicalDrives"`
+	} `json:"Links"`
+}
+
+type redfishLogicalDrive struct {
+	ID                     string        `json:"Id"`
+	LogicalDriveNumber     int           `json:"LogicalDriveNumber"`
+	Raid                   string        `json:"Raid"`
+	CapacityMiB            uint64        `json:"CapacityMiB"`
+	VolumeUniqueIdentifier string        `json:"VolumeUniqueIdentifier"`
+	Status                 redfishStatus `json:"Status"`
+	Links                  struct {
+		DataDrives redfishLink `json:"DataDrives"`
+	} `json:"Links"`
+}
+
+type redfishDiskDrive struct {
+	ID                                string          `json:"Id"`
+	Location                          string          `json:"Location"`
+	Model                             string          `json:"Model"`
+	SerialNumber                      string          `json:"SerialNumber"`
+	FirmwareVersion                   redfishFirmware `json:"FirmwareVersion"`
+	CapacityMiB                       uint64          `json:"CapacityMiB"`
+	InterfaceType                     string          `json:"InterfaceType"`
+	MediaType                         string          `json:"MediaType"`
+	Status                            redfishStatus   `json:"Status"`
+	WWID                              string          `json:"WWID"`
+	CurrentTemperatureCelsius         *int64          `json:"CurrentTemperatureCelsius"`
+	MaximumTemperatureCelsius         *int64          `json:"MaximumTemperatureCelsius"`
+	PowerOnHours                      *int64          `json:"PowerOnHours"` // nolint:misspell
+	SSDEnduranceUtilizationPercentage *float64        `json:"SSDEnduranceUtilizationPercentage"`
+}
+
+// LoadRedfishConfig reads Redfish configuration from a directory where a
+// Secret with keys 'endpoint', 'username', 'password' and (optionally)
+// 'insecure' is mounted.
+func LoadRedfishConfig(dir string) (*RedfishConfig, error) {
+	if dir == "" {
+		return nil, errors.New("no redfish secret")
+	}
+	cfg := &RedfishConfig{}
+	keys := map[string]*string{
+		"endpoint": &cfg.Endpoint,
+		"username": &cfg.Username,
+		"password": &cfg.Password,
+	}
+	for key, val := range keys {
+		dat, err := ioutil.ReadFile(filepath.Join(dir, key))
+		if err != nil {
+			return nil, err
+		}
+		*val = strings.TrimSpace(string(dat))
+	}
+	if _, err := url.Parse(cfg.Endpoint); err != nil || cfg.Endpoint == "" {
+		return nil, fmt.Errorf("illegal redfish endpoint: %q", cfg.Endpoint)
+	}
+	dat, err := ioutil.ReadFile(filepath.Join(dir, "insecure"))
+	if err == nil {
+		cfg.Insecure, _ = strconv.ParseBool(strings.TrimSpace(string(dat)))
+	}
+	return cfg, nil
+}
+
+// redfishClient is a minimal read-only Redfish client, using HTTP basic
+// authentication.
+type redfishClient struct {
+	cfg  *RedfishConfig
+	base *url.URL
+	hc   *http.Client
+}
+
+func newRedfishClient(cfg *RedfishConfig) (*redfishClient, error) {
+	base, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: cfg.Insecure, // nolint:gosec
+	}
+	return &redfishClient{
+		cfg:  cfg,
+		base: base,
+		hc: &http.Client{
+			Transport: tr,
+			Timeout:   redfishRequestTimeout,
+		},
+	}, nil
+}
+
+func (rc *redfishClient) get(ctx context.Context, path string, v interface{}) error {
+	ref := rc.base.ResolveReference(&url.URL{Path: path})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(rc.cfg.Username, rc.cfg.Password)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OData-Version", "4.0")
+	res, err := rc.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("redfish: GET %s: %s", path, res.Status)
+	}
+	dat, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(dat, v)
+}
+
+func (rc *redfishClient) getMembers(ctx context.Context, path string) ([]redfishLink, error) {
+	col := redfishCollection{}
+	if err := rc.get(ctx, path, &col); err != nil {
+		return nil, err
+	}
+	return col.Members, nil
+}
+
+func RunRedfishVersion(ctx context.Context, cfg *RedfishConfig) (string, error) {
+	rc, err := newRedfishClient(cfg)
+	if err != nil {
+		return "", err
+	}
+	root := redfishServiceRoot{}
+	if err := rc.get(ctx, redfishServiceRootPath, &root); err != nil {
+		return "", err
+	}
+	if root.RedfishVersion == "" {
+		return "", errors.New("failed to parse redfish version")
+	}
+	return root.RedfishVersion, nil
+}
+
+// RunRedfishShowAll queries the Smart Array controllers of all systems of
+// HPE iLO via Redfish API and converts them into vendor-neutral
+// representation. Logical drives which share data drives are grouped into
+// the same array, named 'A', 'B', ... as ssacli does.
+func RunRedfishShowAll(ctx context.Context, cfg *RedfishConfig) ([]RaidController, error) {
+	ret := []RaidController{}
+	rc, err := newRedfishClient(cfg)
+	if err != nil {
+		return ret, err
+	}
+	paths, err := rc.getArrayControllersPaths(ctx)
+	if err != nil {
+		return ret, err
+	}
+	for _, path := range paths {
+		links, err := rc.getMembers(ctx, path)
+		if err != nil {
+			return ret, err
+		}
+		for _, link := range links {
+			ctrl, err := rc.getArrayController(ctx, link.ODataID)
+			if err != nil {
+				return ret, err
+			}
+			ret = append(ret, *ctrl)
+		}
+	}
+	return ret, nil
+}
+
+// getArrayControllersPaths walks the members of Systems collection and
+// returns the path of array-controllers collection of each system which has
+// Smart Storage.
+func (rc *redfishClient) getArrayControllersPaths(ctx context.Context) ([]string, error) {
+	root := redfishServiceRoot{}
+	if err := rc.get(ctx, redfishServiceRootPath, &root); err != nil {
+		return nil, err
+	}
+	systemsPath := root.Systems.ODataID
+	if systemsPath == "" {
+		systemsPath = redfishSystemsPath
+	}
+	links, err := rc.getMembers(ctx, systemsPath)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, link := range links {
+		sys := redfishSystem{}
+		if err := rc.get(ctx, link.ODataID, &sys); err != nil {
+			return nil, err
+		}
+		oem := sys.Oem.Hpe
+		if oem == nil {
+			oem = sys.Oem.Hp
+		}
+		if oem == nil || oem.Links.SmartStorage.ODataID == "" {
+			continue
+		}
+		sst := redfishSmartStorage{}
+		if err := rc.get(ctx, oem.Links.SmartStorage.ODataID, &sst); err != nil {
+			return nil, err
+		}
+		if sst.Links.ArrayControllers.ODataID != "" {
+			ret = append(ret, sst.Links.ArrayControllers.ODataID)
+		}
+	}
+	return ret, nil
+}
+
+func (rc *redfishClient) getArrayController(ctx context.Context, path string) (*RaidController, error) {
+	rac := redfishArrayController{}
+	if err := rc.get(ctx, path, &rac); err != nil {
+		return nil, err
+	}
+	ctrl := &RaidController{}
+	ctrl.ID = strings.TrimPrefix(rac.Location, "Slot ")
+	if ctrl.ID == "" {
+		ctrl.ID = rac.ID
+	}
+	ctrl.Model = rac.Model
+	ctrl.Serial = strings.TrimSpace(rac.SerialNumber)
+	ctrl.Firmware = rac.FirmwareVersion.Current.VersionString
+	ctrl.Status = rac.Status.Health
+	if rac.CacheModuleStatus != nil {
+		ctrl.CacheStatus = rac.CacheModuleStatus.Health
+	}
+	ctrl.Temperature = -1
+	if rac.Links.LogicalDrives.ODataID == "" {
+		return ctrl, nil
+	}
+	links, err := rc.getMembers(ctx, rac.Links.LogicalDrives.ODataID)
+	if err != nil {
+		return nil, err
+	}
+	// data drives shared by logical drives are fetched only once
+	pds := map[string]RaidPhysicalDrive{}
+	for _, link := range links {
+		ld, err := rc.getLogicalDrive(ctx, link.ODataID, pds)
+		if err != nil {
+			return nil, err
+		}
+		ctrl.Arrays = appendRedfishLogicalDrive(ctrl.Arrays, ld)
+	}
+	for i := range ctrl.Arrays {
+		arr := &ctrl.Arrays[i]
+		for j := range arr.LogicalDrives {
+			arr.LogicalDrives[j].ArrayName = arr.Name
+			arr.LogicalDrives[j].PhysicalDrives = append([]RaidPhysicalDrive{},
+				arr.PhysicalDrives...)
+		}
+		arr.Status = raidArrayStatus(arr)
+	}
+	return ctrl, nil
+}
+
+// appendRedfishLogicalDrive adds a logical drive to the array which has any
+// of its data drives, or to a new array.
+func appendRedfishLogicalDrive(arrs []RaidArray, ld *RaidLogicalDrive) []RaidArray {
+	for i := range arrs {
+		arr := &arrs[i]
+		if raidPhysicalDrivesOverlap(arr.PhysicalDrives, ld.PhysicalDrives) {
+			arr.LogicalDrives = append(arr.LogicalDrives, *ld)
+			arr.PhysicalDrives = appendRaidPhysicalDrives(arr.PhysicalDrives, ld.PhysicalDrives)
+			return arrs
+		}
+	}
+	arr := RaidArray{Name: redfishArrayName(len(arrs))}
+	arr.LogicalDrives = []RaidLogicalDrive{*ld}
+	arr.PhysicalDrives = appendRaidPhysicalDrives(nil, ld.PhysicalDrives)
+	return append(arrs, arr)
+}
+
+func raidPhysicalDrivesOverlap(pds, other []RaidPhysicalDrive) bool {
+	for _, pd := range pds {
+		for _, opd := range other {
+			if pd.ID == opd.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redfishArrayName returns ssacli-like array name: 'A'...'Z', 'AA'...
+func redfishArrayName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
+
+func (rc *redfishClient) getLogicalDrive(ctx context.Context, path string,
+	pds map[string]RaidPhysicalDrive) (*RaidLogicalDrive, error) {
+	rld := redfishLogicalDrive{}
+	if err := rc.get(ctx, path, &rld); err != nil {
+		return nil, err
+	}
+	ld := &RaidLogicalDrive{}
+	ld.ID = strconv.Itoa(rld.LogicalDriveNumber)
+	ld.SizeBytes = rld.CapacityMiB * Mega
+	ld.Status = rld.Status.Health
+	ld.UniqueID = rld.VolumeUniqueIdentifier
+	ld.RaidLevel = rld.Raid
+	if rld.Links.DataDrives.ODataID == "" {
+		return ld, nil
+	}
+	links, err := rc.getMembers(ctx, rld.Links.DataDrives.ODataID)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		pd, ok := pds[link.ODataID]
+		if !ok {
+			rdd := redfishDiskDrive{}
+			if err := rc.get(ctx, link.ODataID, &rdd); err != nil {
+				return nil, err
+			}
+			pd = redfishDiskDriveToRaid(&rdd)
+			pds[link.ODataID] = pd
+		}
+		ld.PhysicalDrives = append(ld.PhysicalDrives, pd)
+	}
+	return ld, nil
+}
+
+func redfishDiskDriveToRaid(rdd *redfishDiskDrive) RaidPhysicalDrive {
+	pd := RaidPhysicalDrive{}
+	// same notation as ssacli, e.g. 'physicaldrive 1I:1:1'
+	pd.ID = "physicaldrive " + rdd.Location
+	loc := strings.Split(rdd.Location, ":")
+	if len(loc) == 3 {
+		pd.Box = loc[1]
+		pd.Bay = loc[2]
+	}
+	pd.Model = strings.TrimSpace(rdd.Model)
+	pd.Serial = strings.TrimSpace(rdd.SerialNumber)
+	pd.Firmware = rdd.FirmwareVersion.Current.VersionString
+	pd.MediaType = rdd.MediaType
+	pd.Interface = rdd.InterfaceType
+	pd.SizeBytes = rdd.CapacityMiB * Mega
+	pd.Status = rdd.Status.Health
+	pd.UniqueID = rdd.WWID
+	pd.TempCurr = -1
+	if rdd.CurrentTemperatureCelsius != nil {
+		pd.TempCurr = *rdd.CurrentTemperatureCelsius
+	}
+	pd.TempMaxi = -1
+	if rdd.MaximumTemperatureCelsius != nil {
+		pd.TempMaxi = *rdd.MaximumTemperatureCelsius
+	}
+	pd.PowerHours = -1
+	if rdd.PowerOnHours != nil {
+		pd.PowerHours = *rdd.PowerOnHours
+	}
+	pd.UsageRemaining = -1
+	if rdd.SSDEnduranceUtilizationPercentage != nil {
+		pd.UsageRemaining = 100 - *rdd.SSDEnduranceUtilizationPercentage
+	}
+	return pd
+}
+
+// redfishBackend is the RAID backend of HPE Smart Array controllers, based on
+// iLO Redfish API (for hosts where ssacli can not be installed).
+type redfishBackend struct {
+	dir string
+}
+
+func newRedfishBackend(dir string) *redfishBackend {
+	return &redfishBackend{dir: dir}
+}
+
+func (*redfishBackend) Name() string {
+	return "redfish"
+}
+
+func (*redfishBackend) Vendor() string {
+	return "hpe"
+}
+
+func (rbe *redfishBackend) Detect() error {
+	_, err := LoadRedfishConfig(rbe.dir)
+	return err
+}
+
+// Version and Probe re-load config on each call, so that rotated
+// credentials are picked up from the mounted Secret.
+func (rbe *redfishBackend) Version(ctx context.Context) (string, error) {
+	cfg, err := LoadRedfishConfig(rbe.dir)
+	if err != nil {
+		return "", err
+	}
+	return RunRedfishVersion(ctx, cfg)
+}
+
+func (rbe *redfishBackend) Probe(ctx context.Context) ([]RaidController, error) {
+	cfg, err := LoadRedfishConfig(rbe.dir)
+	if err != nil {
+		return nil, err
+	}
+	return RunRedfishShowAll(ctx, cfg)
+}
@@ -0,0 +1,356 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+// Recorded responses of HPE iLO 5 Redfish API, keyed by request path
+var redfishResponses1 = map[string]string{
+	"/redfish/v1/": `{
+  "@odata.context": "/redfish/v1/$metadata#ServiceRoot.ServiceRoot",
+  "@odata.id": "/redfish/v1/",
+  "@odata.type": "#ServiceRoot.v1_5_1.ServiceRoot",
+  "Id": "RootService",
+  "Name": "HPE RESTful Root Service",
+  "Product": "ProLiant DL380 Gen10",
+  "RedfishVersion": "1.6.0",
+  "Systems": {"@odata.id": "/redfish/v1/Systems/"}
+}`,
+	"/redfish/v1/Systems/": `{
+  "@odata.context": "/redfish/v1/$metadata#ComputerSystemCollection.ComputerSystemCollection",
+  "@odata.id": "/redfish/v1/Systems/",
+  "@odata.type": "#ComputerSystemCollection.ComputerSystemCollection",
+  "Members": [
+    {"@odata.id": "/redfish/v1/Systems/1/"}
+  ],
+  "Members@odata.count": 1,
+  "Name": "Computer Systems"
+}`,
+	"/redfish/v1/Systems/1/": `{
+  "@odata.context": "/redfish/v1/$metadata#ComputerSystem.ComputerSystem",
+  "@odata.id": "/redfish/v1/Systems/1/",
+  "@odata.type": "#ComputerSystem.v1_4_0.ComputerSystem",
+  "Id": "1",
+  "Manufacturer": "HPE",
+  "Model": "ProLiant DL380 Gen10",
+  "Oem": {
+    "Hpe": {
+      "Links": {
+        "SmartStorage": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/"}
+      }
+    }
+  }
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/": `{
+  "@odata.context": "/redfish/v1/$metadata#HpeSmartStorage.HpeSmartStorage",
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/",
+  "@odata.type": "#HpeSmartStorage.v2_0_0.HpeSmartStorage",
+  "Id": "SmartStorage",
+  "Name": "HpeSmartStorage",
+  "Status": {"Health": "OK"},
+  "Links": {
+    "ArrayControllers": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/"}
+  }
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/": `{
+  "@odata.context": "/redfish/v1/$metadata#HpeSmartStorageArrayControllerCollection.HpeSmartStorageArrayControllerCollection",
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/",
+  "@odata.type": "#HpeSmartStorageArrayControllerCollection.HpeSmartStorageArrayControllerCollection",
+  "Description": "HPE Smart Storage Array Controllers View",
+  "Members": [
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/"}
+  ],
+  "Members@odata.count": 1,
+  "Name": "HpeSmartStorageArrayControllers"
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/": `{
+  "@odata.context": "/redfish/v1/$metadata#HpeSmartStorageArrayController.HpeSmartStorageArrayController",
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/",
+  "@odata.type": "#HpeSmartStorageArrayController.v2_3_0.HpeSmartStorageArrayController",
+  "Id": "0",
+  "AdapterType": "SmartArray",
+  "CacheMemorySizeMiB": 2048,
+  "CacheModuleStatus": {"Health": "OK"},
+  "ControllerBoard": {"Status": {"Health": "OK"}},
+  "CurrentOperatingMode": "Mixed",
+  "FirmwareVersion": {"Current": {"VersionString": "1.98"}},
+  "Location": "Slot 0",
+  "LocationFormat": "PCISlot",
+  "Model": "HPE Smart Array P408i-a SR Gen10",
+  "Name": "HpeSmartStorageArrayController",
+  "SerialNumber": "PEYHB0ARH9Q0HU ",
+  "Status": {"Health": "OK", "State": "Enabled"},
+  "Links": {
+    "LogicalDrives": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/"},
+    "PhysicalDrives": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/"},
+    "StorageEnclosures": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/StorageEnclosures/"}
+  }
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/",
+  "Members": [
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/1/"},
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/2/"},
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/3/"}
+  ],
+  "Members@odata.count": 3
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/1/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/1/",
+  "@odata.type": "#HpeSmartStorageLogicalDrive.v2_3_0.HpeSmartStorageLogicalDrive",
+  "Id": "1",
+  "AccelerationMethod": "ControllerCache",
+  "CapacityMiB": 286070,
+  "InterfaceType": "SAS",
+  "LogicalDriveName": "00A8D4F1PEYHB0ARH9Q0HU2F3E",
+  "LogicalDriveNumber": 1,
+  "MediaType": "HDD",
+  "Raid": "1",
+  "Status": {"Health": "Warning", "State": "Enabled"},
+  "StripeSizeBytes": 262144,
+  "VolumeUniqueIdentifier": "600508B1001C32B269EB8948F3E5A8E4",
+  "Links": {
+    "DataDrives": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/1/DataDrives/"}
+  }
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/1/DataDrives/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/1/DataDrives/",
+  "Members": [
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/0/"},
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/1/"}
+  ],
+  "Members@odata.count": 2
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/2/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/2/",
+  "@odata.type": "#HpeSmartStorageLogicalDrive.v2_3_0.HpeSmartStorageLogicalDrive",
+  "Id": "2",
+  "CapacityMiB": 100000,
+  "LogicalDriveNumber": 2,
+  "Raid": "1",
+  "Status": {"Health": "OK", "State": "Enabled"},
+  "VolumeUniqueIdentifier": "600508B1001C5D2F1A6E4C0B7F3D2A91",
+  "Links": {
+    "DataDrives": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/2/DataDrives/"}
+  }
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/2/DataDrives/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/2/DataDrives/",
+  "Members": [
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/0/"},
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/1/"}
+  ],
+  "Members@odata.count": 2
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/3/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/3/",
+  "@odata.type": "#HpeSmartStorageLogicalDrive.v2_3_0.HpeSmartStorageLogicalDrive",
+  "Id": "3",
+  "CapacityMiB": 286070,
+  "LogicalDriveNumber": 3,
+  "Raid": "0",
+  "Status": {"Health": "OK", "State": "Enabled"},
+  "VolumeUniqueIdentifier": "600508B1001C8E4D3B2A1F0E9D8C7B6A",
+  "Links": {
+    "DataDrives": {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/3/DataDrives/"}
+  }
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/3/DataDrives/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/LogicalDrives/3/DataDrives/",
+  "Members": [
+    {"@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/2/"}
+  ],
+  "Members@odata.count": 1
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/0/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/0/",
+  "@odata.type": "#HpeSmartStorageDiskDrive.v2_1_0.HpeSmartStorageDiskDrive",
+  "Id": "0",
+  "CapacityMiB": 286102,
+  "CurrentTemperatureCelsius": 30,
+  "FirmwareVersion": {"Current": {"VersionString": "HPD4"}},
+  "InterfaceType": "SAS",
+  "Location": "1I:1:1",
+  "LocationFormat": "ControllerPort:Box:Bay",
+  "MaximumTemperatureCelsius": 42,
+  "MediaType": "HDD",
+  "Model": "EG0300JEHLV",
+  "PowerOnHours": 19284,
+  "SerialNumber": "WFK0ZJ1X0000K9270Q5Z",
+  "Status": {"Health": "OK", "State": "Enabled"},
+  "WWID": "5000C500A6A0F3B1"
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/1/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/1/",
+  "@odata.type": "#HpeSmartStorageDiskDrive.v2_1_0.HpeSmartStorageDiskDrive",
+  "Id": "1",
+  "CapacityMiB": 457862,
+  "CurrentTemperatureCelsius": 27,
+  "FirmwareVersion": {"Current": {"VersionString": "HPG3"}},
+  "InterfaceType": "SATA",
+  "Location": "1I:1:2",
+  "LocationFormat": "ControllerPort:Box:Bay",
+  "MaximumTemperatureCelsius": 35,
+  "MediaType": "SSD",
+  "Model": "MK000480GWXFF",
+  "PowerOnHours": 19280,
+  "SSDEnduranceUtilizationPercentage": 3,
+  "SerialNumber": "S4EVNX0M901234",
+  "Status": {"Health": "Warning", "State": "Enabled"},
+  "WWID": "5002538E0012A3B4"
+}`,
+	"/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/2/": `{
+  "@odata.id": "/redfish/v1/Systems/1/SmartStorage/ArrayControllers/0/DiskDrives/2/",
+  "@odata.type": "#HpeSmartStorageDiskDrive.v2_1_0.HpeSmartStorageDiskDrive",
+  "Id": "2",
+  "CapacityMiB": 286102,
+  "CurrentTemperatureCelsius": 29,
+  "FirmwareVersion": {"Current": {"VersionString": "HPD4"}},
+  "InterfaceType": "SAS",
+  "Location": "1I:1:3",
+  "LocationFormat": "ControllerPort:Box:Bay",
+  "MaximumTemperatureCelsius": 40,
+  "MediaType": "HDD",
+  "Model": "EG0300JEHLV",
+  "PowerOnHours": 19270,
+  "SerialNumber": "WFK0ZK2B0000K9270R1A",
+  "Status": {"Health": "OK", "State": "Enabled"},
+  "WWID": "5000C500A6A1C4D2"
+}`,
+}
+
+func newRedfishStandIn(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "monitor" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		dat, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(dat))
+		assert.NoError(t, err)
+	}))
+}
+
+func TestRedfishShowAll(t *testing.T) {
+	srv := newRedfishStandIn(t, redfishResponses1)
+	defer srv.Close()
+
+	cfg := &devmon.RedfishConfig{
+		Endpoint: srv.URL,
+		Username: "monitor",
+		Password: "secret",
+	}
+	vers, err := devmon.RunRedfishVersion(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, vers, "1.6.0")
+
+	ctrls, err := devmon.RunRedfishShowAll(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ctrls), 1)
+
+	ctrl := ctrls[0]
+	assert.Equal(t, ctrl.ID, "0")
+	assert.Equal(t, ctrl.Model, "HPE Smart Array P408i-a SR Gen10")
+	assert.Equal(t, ctrl.Serial, "PEYHB0ARH9Q0HU")
+	assert.Equal(t, ctrl.Firmware, "1.98")
+	assert.Equal(t, ctrl.Status, "OK")
+	assert.Equal(t, ctrl.CacheStatus, "OK")
+	assert.Equal(t, ctrl.Temperature, int64(-1))
+	assert.Equal(t, len(ctrl.Arrays), 2)
+
+	// logical drives 1 and 2 share their data drives
+	arr := ctrl.Arrays[0]
+	assert.Equal(t, arr.Name, "A")
+	assert.Equal(t, arr.Status, "Warning")
+	assert.Equal(t, len(arr.LogicalDrives), 2)
+	assert.Equal(t, len(arr.PhysicalDrives), 2)
+	assert.Equal(t, arr.LogicalDrives[1].ID, "2")
+	assert.Equal(t, arr.LogicalDrives[1].ArrayName, "A")
+	assert.Equal(t, len(arr.LogicalDrives[1].PhysicalDrives), 2)
+
+	arr2 := ctrl.Arrays[1]
+	assert.Equal(t, arr2.Name, "B")
+	assert.Equal(t, arr2.Status, "OK")
+	assert.Equal(t, len(arr2.LogicalDrives), 1)
+	assert.Equal(t, len(arr2.PhysicalDrives), 1)
+	assert.Equal(t, arr2.PhysicalDrives[0].ID, "physicaldrive 1I:1:3")
+
+	ld := arr.LogicalDrives[0]
+	assert.Equal(t, ld.ID, "1")
+	assert.Equal(t, ld.ArrayName, "A")
+	assert.Equal(t, ld.RaidLevel, "1")
+	assert.Equal(t, ld.UniqueID, "600508B1001C32B269EB8948F3E5A8E4")
+	assert.Equal(t, ld.SizeBytes, uint64(286070*devmon.Mega))
+
+	pd0 := ld.PhysicalDrives[0]
+	assert.Equal(t, pd0.ID, "physicaldrive 1I:1:1")
+	assert.Equal(t, pd0.Box, "1")
+	assert.Equal(t, pd0.Bay, "1")
+	assert.Equal(t, pd0.Serial, "WFK0ZJ1X0000K9270Q5Z")
+	assert.Equal(t, pd0.Firmware, "HPD4")
+	assert.Equal(t, pd0.UniqueID, "5000C500A6A0F3B1")
+	assert.Equal(t, pd0.TempCurr, int64(30))
+	assert.Equal(t, pd0.TempMaxi, int64(42))
+	assert.Equal(t, pd0.PowerHours, int64(19284))
+	assert.Equal(t, pd0.UsageRemaining, float64(-1))
+
+	pd1 := ld.PhysicalDrives[1]
+	assert.Equal(t, pd1.MediaType, "SSD")
+	assert.Equal(t, pd1.Status, "Warning")
+	assert.Equal(t, pd1.UsageRemaining, float64(97))
+
+	bdi := devmon.BlkdevInfo{Name: "sda", WWN: "600508b1001c32b269eb8948f3e5a8e4"}
+	_, rld := devmon.LookupRaidLogicalDrive(ctrls, &bdi)
+	assert.NotNil(t, rld)
+
+	cfg.Password = "wrong"
+	_, err = devmon.RunRedfishShowAll(context.Background(), cfg)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "401"))
+}
+
+func TestLoadRedfishConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redfish")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = devmon.LoadRedfishConfig(dir)
+	assert.Error(t, err)
+
+	keys := map[string]string{
+		"endpoint": "https://16.1.15.1\n",
+		"username": "monitor",
+		"password": "secret",
+		"insecure": "true",
+	}
+	for key, val := range keys {
+		err = ioutil.WriteFile(filepath.Join(dir, key), []byte(val), 0600)
+		assert.NoError(t, err)
+	}
+	cfg, err := devmon.LoadRedfishConfig(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Endpoint, "https://16.1.15.1")
+	assert.Equal(t, cfg.Username, "monitor")
+	assert.Equal(t, cfg.Password, "secret")
+	assert.True(t, cfg.Insecure)
+
+	_, err = devmon.LoadRedfishConfig("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// smartctl exit-status bits which indicate that no data was retrieved:
+	// command-line parse error and device open failure. See man(8) smartctl
+	smartctlExitFatalMask = 0x3
+
+	// upper bound on cciss drive index, when probing drives behind controller
+	smartctlMaxCcissIndex = 128
+	// number of consecutive cciss indices without a drive after which the
+	// probing of controller's drives stops
+	smartctlMaxCcissMisses = 8
+)
+
+// SmartDriveInfo represents the key SMART attributes of physical drive, as
+// reported by smartctl. Unknown (or not applicable) values are set to -1, and
+// Passed is nil when smartctl does not report the overall health status.
+type SmartDriveInfo struct {
+	Index                int    `json:"index"`
+	Model                string `json:"model"`
+	Serial               string `json:"serial"`
+	Protocol             string `json:"protocol"`
+	Passed               *bool  `json:"passed,omitempty"`
+	PowerOnHours         int64  `json:"poweronhours"`
+	Temperature          int64  `json:"temperature"`
+	ReallocatedSectors   int64  `json:"reallocatedsectors"`
+	PendingSectors       int64  `json:"pendingsectors"`
+	OfflineUncorrectable int64  `json:"offlineuncorrectable"`
+	CRCErrors            int64  `json:"crcerrors"`
+}
+
+// smartctlOutput is the subset of 'smartctl --json' output used by exporter
+type smartctlOutput struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+	} `json:"smartctl"`
+	Device struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	ScsiModel    string `json:"scsi_model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	PowerOnTime *struct {
+		Hours int64 `json:"hours"`
+	} `json:"power_on_time"`
+	Temperature *struct {
+		Current int64 `json:"current"`
+	} `json:"temperature"`
+	AtaSmartAttributes *struct {
+		Table []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+			Raw  struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	ScsiGrownDefectList *int64 `json:"scsi_grown_defect_list"`
+}
+
+// ATA SMART attributes IDs
+const (
+	ataAttrReallocatedSectors   = 5
+	ataAttrPendingSectors       = 197
+	ataAttrOfflineUncorrectable = 198
+	ataAttrUDMACRCErrors        = 199
+)
+
+func LocateSmartctl() (string, error) {
+	knowns := []string{
+		"/usr/sbin/smartctl",
+		"/usr/bin/smartctl",
+		"/usr/local/sbin/smartctl",
+		"/opt/smartmontools/sbin/smartctl",
+	}
+	return locateTool("smartctl", knowns)
+}
+
+// RunSmartctlCciss queries SMART data of physical drive at index behind a
+// Smart Array controller, using cciss pass-through via one of the
+// controller's logical devices (e.g. /dev/sda).
+func RunSmartctlCciss(ctx context.Context, device string, index int) (*SmartDriveInfo, error) {
+	loc, err := LocateSmartctl()
+	if err != nil {
+		return nil, err
+	}
+	dtype := fmt.Sprintf("cciss,%d", index)
+	out, err := executeCommand(ctx, loc, "--json", "--all", "-d", dtype, device)
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	sdi, err := ParseSmartctlJSON(out)
+	if err != nil {
+		return nil, err
+	}
+	sdi.Index = index
+	return sdi, nil
+}
+
+// ParseSmartctlJSON converts the output of 'smartctl --json --all' into
+// SmartDriveInfo representation.
+func ParseSmartctlJSON(dat string) (*SmartDriveInfo, error) {
+	out := smartctlOutput{}
+	if err := json.Unmarshal([]byte(dat), &out); err != nil {
+		return nil, err
+	}
+	if (out.Smartctl.ExitStatus & smartctlExitFatalMask) != 0 {
+		return nil, fmt.Errorf("smartctl failed: exit_status=%d",
+			out.Smartctl.ExitStatus)
+	}
+	sdi := &SmartDriveInfo{
+		Model:                out.ModelName,
+		Serial:               strings.TrimSpace(out.SerialNumber),
+		Protocol:             out.Device.Protocol,
+		PowerOnHours:         -1,
+		Temperature:          -1,
+		ReallocatedSectors:   -1,
+		PendingSectors:       -1,
+		OfflineUncorrectable: -1,
+		CRCErrors:            -1,
+	}
+	if sdi.Model == "" {
+		sdi.Model = out.ScsiModel
+	}
+	if out.SmartStatus != nil {
+		passed := out.SmartStatus.Passed
+		sdi.Passed = &passed
+	}
+	if out.PowerOnTime != nil {
+		sdi.PowerOnHours = out.PowerOnTime.Hours
+	}
+	if out.Temperature != nil {
+		sdi.Temperature = out.Temperature.Current
+	}
+	if out.AtaSmartAttributes != nil {
+		for _, attr := range out.AtaSmartAttributes.Table {
+			switch attr.ID {
+			case ataAttrReallocatedSectors:
+				sdi.ReallocatedSectors = attr.Raw.Value
+			case ataAttrPendingSectors:
+				sdi.PendingSectors = attr.Raw.Value
+			case ataAttrOfflineUncorrectable:
+				sdi.OfflineUncorrectable = attr.Raw.Value
+			case ataAttrUDMACRCErrors:
+				sdi.CRCErrors = attr.Raw.Value
+			}
+		}
+	}
+	if out.ScsiGrownDefectList != nil {
+		sdi.ReallocatedSectors = *out.ScsiGrownDefectList
+	}
+	return sdi, nil
+}
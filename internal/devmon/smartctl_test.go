@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	smartctlCcissATA = `{
+  "json_format_version": [1, 0],
+  "smartctl": {
+    "version": [7, 1],
+    "argv": ["smartctl", "--json", "--all", "-d", "cciss,1", "/dev/sda"],
+    "exit_status": 0
+  },
+  "device": {
+    "name": "/dev/sda",
+    "info_name": "/dev/sda [cciss_disk_01] [SAT]",
+    "type": "sat+cciss",
+    "protocol": "ATA"
+  },
+  "model_name": "INTEL SSDSC2BA400G4",
+  "serial_number": "BTHV603000TL400NGN",
+  "firmware_version": "G2010150",
+  "smart_status": {
+    "passed": true
+  },
+  "ata_smart_attributes": {
+    "revision": 1,
+    "table": [
+      {"id": 5, "name": "Reallocated_Sector_Ct", "value": 100, "worst": 100,
+       "thresh": 0, "raw": {"value": 2, "string": "2"}},
+      {"id": 9, "name": "Power_On_Hours", "value": 100, "worst": 100,
+       "thresh": 0, "raw": {"value": 40698, "string": "40698"}},
+      {"id": 197, "name": "Current_Pending_Sector", "value": 100, "worst": 100,
+       "thresh": 0, "raw": {"value": 1, "string": "1"}},
+      {"id": 199, "name": "UDMA_CRC_Error_Count", "value": 100, "worst": 100,
+       "thresh": 0, "raw": {"value": 7, "string": "7"}}
+    ]
+  },
+  "power_on_time": {
+    "hours": 40698
+  },
+  "temperature": {
+    "current": 24
+  }
+}`
+
+	smartctlCcissSCSI = `{
+  "smartctl": {
+    "exit_status": 4
+  },
+  "device": {
+    "name": "/dev/sdb",
+    "info_name": "/dev/sdb [cciss_disk_00]",
+    "type": "cciss",
+    "protocol": "SCSI"
+  },
+  "scsi_model_name": "SEAGATE ST6000NM0285",
+  "serial_number": "ZA19G381",
+  "smart_status": {
+    "passed": false
+  },
+  "temperature": {
+    "current": 34
+  },
+  "power_on_time": {
+    "hours": 21512,
+    "minutes": 44
+  },
+  "scsi_grown_defect_list": 12
+}`
+
+	smartctlCcissNoStatus = `{
+  "smartctl": {
+    "exit_status": 4
+  },
+  "device": {
+    "name": "/dev/sda",
+    "info_name": "/dev/sda [cciss_disk_02]",
+    "type": "cciss",
+    "protocol": "SCSI"
+  },
+  "scsi_model_name": "HP EG0600FBVFP",
+  "serial_number": "KWJ1RN4F",
+  "temperature": {
+    "current": 29
+  }
+}`
+
+	smartctlCcissNoDevice = `{
+  "smartctl": {
+    "exit_status": 2,
+    "messages": [
+      {"string": "/dev/sda [cciss_disk_09]: No such device", "severity": "error"}
+    ]
+  }
+}`
+)
+
+func TestParseSmartctlJSON(t *testing.T) {
+	sdi, err := devmon.ParseSmartctlJSON(smartctlCcissATA)
+	assert.NoError(t, err)
+	assert.Equal(t, sdi.Serial, "BTHV603000TL400NGN")
+	assert.Equal(t, sdi.Protocol, "ATA")
+	assert.Equal(t, true, *sdi.Passed)
+	assert.Equal(t, sdi.PowerOnHours, int64(40698))
+	assert.Equal(t, sdi.Temperature, int64(24))
+	assert.Equal(t, sdi.ReallocatedSectors, int64(2))
+	assert.Equal(t, sdi.PendingSectors, int64(1))
+	assert.Equal(t, sdi.OfflineUncorrectable, int64(-1))
+	assert.Equal(t, sdi.CRCErrors, int64(7))
+
+	sdi, err = devmon.ParseSmartctlJSON(smartctlCcissSCSI)
+	assert.NoError(t, err)
+	assert.Equal(t, sdi.Serial, "ZA19G381")
+	assert.Equal(t, sdi.Model, "SEAGATE ST6000NM0285")
+	assert.Equal(t, false, *sdi.Passed)
+	assert.Equal(t, sdi.PowerOnHours, int64(21512))
+	assert.Equal(t, sdi.ReallocatedSectors, int64(12))
+	assert.Equal(t, sdi.PendingSectors, int64(-1))
+
+	sdi, err = devmon.ParseSmartctlJSON(smartctlCcissNoStatus)
+	assert.NoError(t, err)
+	assert.Equal(t, sdi.Serial, "KWJ1RN4F")
+	assert.Nil(t, sdi.Passed)
+	assert.Equal(t, sdi.Temperature, int64(29))
+	assert.Equal(t, sdi.PowerOnHours, int64(-1))
+
+	_, err = devmon.ParseSmartctlJSON(smartctlCcissNoDevice)
+	assert.Error(t, err)
+	_, err = devmon.ParseSmartctlJSON("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,434 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// storcliOutput is the common envelope of 'storcli ... J' (JSON) output
+type storcliOutput struct {
+	Controllers []struct {
+		CommandStatus struct {
+			Controller  interface{} `json:"Controller"`
+			Status      string      `json:"Status"`
+			Description string      `json:"Description"`
+		} `json:"Command Status"`
+		ResponseData map[string]json.RawMessage `json:"Response Data"`
+	} `json:"Controllers"`
+}
+
+type storcliVirtualDrive struct {
+	DGVD  string `json:"DG/VD"`
+	Type  string `json:"TYPE"`
+	State string `json:"State"`
+	Size  string `json:"Size"`
+	Name  string `json:"Name"`
+}
+
+type storcliPhysicalDrive struct {
+	EIDSlt string      `json:"EID:Slt"`
+	DID    int         `json:"DID"`
+	State  string      `json:"State"`
+	DG     interface{} `json:"DG"`
+	Size   string      `json:"Size"`
+	Intf   string      `json:"Intf"`
+	Med    string      `json:"Med"`
+	Model  string      `json:"Model"`
+}
+
+type storcliControllerInfo struct {
+	Basics struct {
+		Model        string `json:"Model"`
+		SerialNumber string `json:"Serial Number"`
+	} `json:"Basics"`
+	Version struct {
+		FirmwareVersion string `json:"Firmware Version"`
+	} `json:"Version"`
+	Status struct {
+		ControllerStatus string `json:"Controller Status"`
+	} `json:"Status"`
+	HwCfg struct {
+		ROCTemperature *int64 `json:"ROC temperature(Degree Celsius)"`
+	} `json:"HwCfg"`
+	CachevaultInfo []struct {
+		State string `json:"State"`
+	} `json:"Cachevault_Info"`
+	BBUInfo []struct {
+		State string `json:"State"`
+	} `json:"BBU_Info"`
+	PDList []storcliPhysicalDrive `json:"PD LIST"`
+}
+
+// storcliStatusMap maps MegaRAID states (of controllers, virtual drives and
+// physical drives) to the status vocabulary of ssacli.
+var storcliStatusMap = map[string]string{
+	"Optimal": "OK",
+	"Optl":    "OK",
+	"Dgrd":    "Degraded",
+	"Pdgd":    "Partially Degraded",
+	"OfLn":    "Failed",
+	"Rec":     "Recovering",
+	"Onln":    "OK",
+	"UGood":   "OK",
+	"GHS":     "OK",
+	"DHS":     "OK",
+	"JBOD":    "OK",
+	"Offln":   "Offline",
+	"UBad":    "Failed",
+	"Failed":  "Failed",
+	"Rbld":    "Rebuilding",
+	"Cpybck":  "Copyback",
+	"Missing": "Missing",
+}
+
+func LocateStorcli() (string, error) {
+	knowns := []string{
+		"/opt/MegaRAID/storcli/storcli64",
+		"/opt/MegaRAID/perccli/perccli64",
+		"/usr/sbin/storcli64",
+		"/usr/sbin/storcli",
+		"/usr/sbin/perccli64",
+	}
+	return locateTool("storcli", knowns)
+}
+
+func RunStorcliVersion(ctx context.Context) (string, error) {
+	dat, err := executeStorcliCommand(ctx, "-v")
+	if err != nil {
+		return "", err
+	}
+	return ParseStorcliVersion(dat)
+}
+
+func ParseStorcliVersion(dat string) (string, error) {
+	for _, ln := range strings.Split(dat, "\n") {
+		idx := strings.Index(ln, " Ver ")
+		if idx > 0 {
+			return strings.TrimSpace(ln[idx+len(" Ver "):]), nil
+		}
+	}
+	return "", errors.New("failed to parse storcli version")
+}
+
+// RunStorcliShowAll queries controllers, virtual drives and physical drives
+// and converts them into vendor-neutral representation.
+func RunStorcliShowAll(ctx context.Context) ([]RaidController, error) {
+	ctrls, err := executeStorcliCommand(ctx, "/call", "show", "all", "J")
+	if err != nil {
+		return nil, err
+	}
+	vds, err := executeStorcliCommand(ctx, "/call/vall", "show", "all", "J")
+	if err != nil && len(vds) == 0 {
+		return nil, err
+	}
+	pds, err := executeStorcliCommand(ctx, "/call/eall/sall", "show", "all", "J")
+	if err != nil && len(pds) == 0 {
+		return nil, err
+	}
+	return ParseStorcliControllers(ctrls, vds, pds)
+}
+
+func executeStorcliCommand(ctx context.Context, args ...string) (string, error) {
+	loc, err := LocateStorcli()
+	if err != nil {
+		return "", err
+	}
+	return executeCommand(ctx, loc, args...)
+}
+
+// ParseStorcliControllers converts the JSON outputs of 'storcli /call show
+// all J', 'storcli /call/vall show all J' and 'storcli /call/eall/sall show
+// all J' into vendor-neutral representation of RAID controllers. Virtual
+// drives are grouped into arrays by their drive group.
+func ParseStorcliControllers(ctrlsDat, vdsDat, pdsDat string) ([]RaidController, error) {
+	ret := []RaidController{}
+	ctrls, err := parseStorcliOutput(ctrlsDat)
+	if err != nil {
+		return ret, err
+	}
+	vds, err := parseStorcliOutput(vdsDat)
+	if err != nil {
+		return ret, err
+	}
+	pds, err := parseStorcliOutput(pdsDat)
+	if err != nil {
+		return ret, err
+	}
+	ids := []string{}
+	for id := range ctrls {
+		ids = append(ids, id)
+	}
+	for _, id := range sortRaidKeys(ids) {
+		ctrl, err := parseStorcliController(id, ctrls[id])
+		if err != nil {
+			return ret, err
+		}
+		pdm := parseStorcliPhysicalDrives(id, pds[id])
+		ctrl.Arrays = parseStorcliArrays(id, ctrls[id], vds[id], pdm)
+		ret = append(ret, *ctrl)
+	}
+	return ret, nil
+}
+
+// parseStorcliOutput maps controller-id to its response data, ignoring
+// controllers for which the command has failed.
+func parseStorcliOutput(dat string) (map[string]map[string]json.RawMessage, error) {
+	ret := map[string]map[string]json.RawMessage{}
+	if len(strings.TrimSpace(dat)) == 0 {
+		return ret, nil
+	}
+	out := storcliOutput{}
+	if err := json.Unmarshal([]byte(dat), &out); err != nil {
+		return ret, err
+	}
+	for _, ctrl := range out.Controllers {
+		if ctrl.CommandStatus.Status != "Success" || ctrl.ResponseData == nil {
+			continue
+		}
+		id := fmt.Sprint(ctrl.CommandStatus.Controller)
+		ret[id] = ctrl.ResponseData
+	}
+	return ret, nil
+}
+
+func parseStorcliController(id string, rdat map[string]json.RawMessage) (*RaidController, error) {
+	info := storcliControllerInfo{}
+	if err := storcliUnmarshal(rdat, &info); err != nil {
+		return nil, err
+	}
+	ctrl := &RaidController{
+		ID:          id,
+		Model:       strings.TrimSpace(info.Basics.Model),
+		Serial:      strings.TrimSpace(info.Basics.SerialNumber),
+		Firmware:    info.Version.FirmwareVersion,
+		Status:      storcliStatus(info.Status.ControllerStatus),
+		Temperature: -1,
+	}
+	if info.HwCfg.ROCTemperature != nil {
+		ctrl.Temperature = *info.HwCfg.ROCTemperature
+	}
+	if len(info.CachevaultInfo) > 0 {
+		ctrl.BatteryStatus = storcliStatus(info.CachevaultInfo[0].State)
+	} else if len(info.BBUInfo) > 0 {
+		ctrl.BatteryStatus = storcliStatus(info.BBUInfo[0].State)
+	}
+	return ctrl, nil
+}
+
+// parseStorcliPhysicalDrives maps enclosure:slot to detailed physical drive
+// info, as reported by 'storcli /cN/eall/sall show all J'.
+func parseStorcliPhysicalDrives(id string,
+	rdat map[string]json.RawMessage) map[string]RaidPhysicalDrive {
+	ret := map[string]RaidPhysicalDrive{}
+	for key, val := range rdat {
+		if !strings.HasPrefix(key, "Drive ") || strings.Contains(key, " - ") {
+			continue
+		}
+		pds := []storcliPhysicalDrive{}
+		if err := json.Unmarshal(val, &pds); err != nil || len(pds) != 1 {
+			continue
+		}
+		pd := newStorcliPhysicalDrive(id, &pds[0])
+		details := map[string]map[string]interface{}{}
+		if dat, ok := rdat[key+" - Detailed Information"]; ok {
+			_ = json.Unmarshal(dat, &details)
+		}
+		applyStorcliDriveDetails(&pd, details[key+" State"], details[key+" Device attributes"])
+		ret[pds[0].EIDSlt] = pd
+	}
+	return ret
+}
+
+func newStorcliPhysicalDrive(id string, spd *storcliPhysicalDrive) RaidPhysicalDrive {
+	eid, slt := splitStorcliEIDSlt(spd.EIDSlt)
+	pd := RaidPhysicalDrive{}
+	if eid != "" {
+		pd.ID = fmt.Sprintf("/c%s/e%s/s%s", id, eid, slt)
+	} else {
+		pd.ID = fmt.Sprintf("/c%s/s%s", id, slt)
+	}
+	pd.Box = eid
+	pd.Bay = slt
+	pd.Model = strings.TrimSpace(spd.Model)
+	pd.MediaType = spd.Med
+	pd.Interface = spd.Intf
+	pd.SizeBytes = parseSizeBytes(spd.Size)
+	pd.Status = storcliStatus(spd.State)
+	pd.TempCurr = -1
+	pd.TempMaxi = -1
+	pd.PowerHours = -1
+	pd.UsageRemaining = -1
+	return pd
+}
+
+func applyStorcliDriveDetails(pd *RaidPhysicalDrive, state, attrs map[string]interface{}) {
+	if temp, ok := state["Drive Temperature"].(string); ok {
+		pd.TempCurr = parseTempOr(strings.Split(strings.TrimSpace(temp), "C")[0], -1)
+	}
+	predictive := false
+	if cnt, ok := state["Predictive Failure Count"].(float64); ok && cnt > 0 {
+		predictive = true
+	}
+	if flag, ok := state["S.M.A.R.T alert flagged by drive"].(string); ok && flag == "Yes" {
+		predictive = true
+	}
+	if predictive && pd.Status == "OK" {
+		pd.Status = "Predictive Failure"
+	}
+	if sn, ok := attrs["SN"].(string); ok {
+		pd.Serial = strings.TrimSpace(sn)
+	}
+	if wwn, ok := attrs["WWN"].(string); ok {
+		pd.UniqueID = strings.TrimSpace(wwn)
+	}
+	if fw, ok := attrs["Firmware Revision"].(string); ok {
+		pd.Firmware = strings.TrimSpace(fw)
+	}
+}
+
+// parseStorcliArrays groups virtual drives (with their physical drives) by
+// drive group, as reported by 'storcli /cN/vall show all J'.
+func parseStorcliArrays(id string, crdat, vrdat map[string]json.RawMessage,
+	pdm map[string]RaidPhysicalDrive) []RaidArray {
+	arrs := map[string]*RaidArray{}
+	info := storcliControllerInfo{}
+	_ = storcliUnmarshal(crdat, &info)
+	for _, spd := range info.PDList {
+		dg := fmt.Sprint(spd.DG)
+		if dg == "-" || dg == "<nil>" {
+			continue
+		}
+		arr := storcliArrayOf(arrs, dg)
+		arr.PhysicalDrives = append(arr.PhysicalDrives, storcliPhysicalDriveOf(id, &spd, pdm))
+	}
+	prefix := fmt.Sprintf("/c%s/v", id)
+	for key, val := range vrdat {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		svds := []storcliVirtualDrive{}
+		if err := json.Unmarshal(val, &svds); err != nil || len(svds) != 1 {
+			continue
+		}
+		vdnum := strings.TrimPrefix(key, prefix)
+		ld := newStorcliLogicalDrive(&svds[0], vrdat["VD"+vdnum+" Properties"])
+		spds := []storcliPhysicalDrive{}
+		_ = json.Unmarshal(vrdat["PDs for VD "+vdnum], &spds)
+		for i := range spds {
+			ld.PhysicalDrives = append(ld.PhysicalDrives,
+				storcliPhysicalDriveOf(id, &spds[i], pdm))
+		}
+		arr := storcliArrayOf(arrs, ld.ArrayName)
+		arr.LogicalDrives = append(arr.LogicalDrives, ld)
+	}
+	dgs := []string{}
+	for dg := range arrs {
+		dgs = append(dgs, dg)
+	}
+	ret := []RaidArray{}
+	for _, dg := range sortRaidKeys(dgs) {
+		arr := arrs[dg]
+		sort.Slice(arr.LogicalDrives, func(i, j int) bool {
+			return arr.LogicalDrives[i].ID < arr.LogicalDrives[j].ID
+		})
+		arr.Status = raidArrayStatus(arr)
+		ret = append(ret, *arr)
+	}
+	return ret
+}
+
+func newStorcliLogicalDrive(svd *storcliVirtualDrive, props json.RawMessage) RaidLogicalDrive {
+	dgvd := strings.Split(svd.DGVD, "/")
+	ld := RaidLogicalDrive{}
+	ld.ArrayName = dgvd[0]
+	if len(dgvd) == 2 {
+		ld.ID = dgvd[1]
+	}
+	ld.SizeBytes = parseSizeBytes(svd.Size)
+	ld.Status = storcliStatus(svd.State)
+	ld.RaidLevel = svd.Type
+	vprops := map[string]interface{}{}
+	_ = json.Unmarshal(props, &vprops)
+	if name, ok := vprops["OS Drive Name"].(string); ok {
+		ld.DiskName = name
+	}
+	if naa, ok := vprops["SCSI NAA Id"].(string); ok {
+		ld.UniqueID = naa
+	}
+	return ld
+}
+
+func storcliPhysicalDriveOf(id string, spd *storcliPhysicalDrive,
+	pdm map[string]RaidPhysicalDrive) RaidPhysicalDrive {
+	if pd, ok := pdm[spd.EIDSlt]; ok {
+		return pd
+	}
+	return newStorcliPhysicalDrive(id, spd)
+}
+
+func storcliArrayOf(arrs map[string]*RaidArray, dg string) *RaidArray {
+	arr, ok := arrs[dg]
+	if !ok {
+		arr = &RaidArray{Name: dg}
+		arrs[dg] = arr
+	}
+	return arr
+}
+
+func storcliUnmarshal(rdat map[string]json.RawMessage, v interface{}) error {
+	dat, err := json.Marshal(rdat)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(dat, v)
+}
+
+func storcliStatus(state string) string {
+	state = strings.TrimSpace(state)
+	if status, ok := storcliStatusMap[state]; ok {
+		return status
+	}
+	return state
+}
+
+func splitStorcliEIDSlt(eidslt string) (string, string) {
+	kv := strings.Split(eidslt, ":")
+	if len(kv) != 2 {
+		return "", strings.TrimSpace(eidslt)
+	}
+	return strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+}
+
+// storcliBackend is the RAID backend of Broadcom (LSI/Avago) MegaRAID
+// controllers, based on storcli (or Dell's perccli) tool.
+type storcliBackend struct{}
+
+func newStorcliBackend() *storcliBackend {
+	return &storcliBackend{}
+}
+
+func (*storcliBackend) Name() string {
+	return "storcli"
+}
+
+func (*storcliBackend) Vendor() string {
+	return "broadcom"
+}
+
+func (*storcliBackend) Detect() error {
+	_, err := LocateStorcli()
+	return err
+}
+
+func (*storcliBackend) Version(ctx context.Context) (string, error) {
+	return RunStorcliVersion(ctx)
+}
+
+func (*storcliBackend) Probe(ctx context.Context) ([]RaidController, error) {
+	return RunStorcliShowAll(ctx)
+}
@@ -0,0 +1,737 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+var storcliVersion1 = `
+     StorCli SAS Customization Utility Ver 007.1017.0000.0000 May 10, 2019
+
+    (c)Copyright 2018, AVAGO Technologies, All Rights Reserved.
+
+`
+
+var storcliCallShowAll1 = `
+{
+"Controllers":[
+{
+	"Command Status" : {
+		"CLI Version" : "007.1017.0000.0000 May 10, 2019",
+		"Operating system" : "Linux 4.18.0-305.el8.x86_64",
+		"Controller" : 0,
+		"Status" : "Success",
+		"Description" : "None"
+	},
+	"Response Data" : {
+		"Basics" : {
+			"Controller" : 0,
+			"Model" : "PERC H730P Mini",
+			"Serial Number" : "8AF00VK",
+			"Current Controller Date/Time" : "03/14/2022, 08:27:51",
+			"Current System Date/time" : "03/14/2022, 09:27:53",
+			"SAS Address" : "5d0946606ad6c700",
+			"PCI Address" : "00:18:00:00",
+			"Mfg Date" : "08/12/18",
+			"Rework Date" : "08/12/18",
+			"Revision No" : "A05"
+		},
+		"Version" : {
+			"Firmware Package Build" : "25.5.6.0009",
+			"Firmware Version" : "4.300.00-8364",
+			"Bios Version" : "6.33.01.0_4.19.08.00_0x06120304",
+			"Ctrl-R Version" : "5.19-0603",
+			"Preboot CLI Version" : "01.00-05:#%0000",
+			"NVDATA Version" : "3.1511.00-0028",
+			"Boot Block Version" : "3.07.00.00-0003",
+			"Driver Name" : "megaraid_sas",
+			"Driver Version" : "07.714.04.00-rh1"
+		},
+		"Status" : {
+			"Controller Status" : "Optimal",
+			"Memory Correctable Errors" : 0,
+			"Memory Uncorrectable Errors" : 0,
+			"ECC Bucket Count" : 0,
+			"Any Offline VD Cache Preserved" : "No",
+			"BBU Status" : 0,
+			"PD Firmware Download in progress" : "No",
+			"Support PD Firmware Download" : "Yes",
+			"Lock Key Assigned" : "No",
+			"Failed to get lock key on bootup" : "No",
+			"Lock key has not been backed up" : "No",
+			"Bios was not detected during boot" : "No",
+			"Controller must be rebooted to complete security operation" : "No",
+			"A rollback operation is in progress" : "No",
+			"At least one PFK exists in NVRAM" : "No",
+			"SSC Policy is WB" : "No",
+			"Controller has booted into safe mode" : "No"
+		},
+		"HwCfg" : {
+			"ChipRevision" : " C0",
+			"BatteryFRU" : "N/A",
+			"Front End Port Count" : 0,
+			"Backend Port Count" : 8,
+			"BBU" : "Present",
+			"Alarm" : "Absent",
+			"Serial Debugger" : "Present",
+			"NVRAM Size" : "32KB",
+			"Flash Size" : "16MB",
+			"On Board Memory Size" : "2048MB",
+			"CacheVault Flash Size" : "N/A",
+			"TPM" : "Absent",
+			"Upgrade Key" : "Absent",
+			"On Board Expander" : "Absent",
+			"Temperature Sensor for ROC" : "Present",
+			"Temperature Sensor for Controller" : "Absent",
+			"Current Size of CacheCade (GB)" : 0,
+			"Current Size of FW Cache (MB)" : 1676,
+			"ROC temperature(Degree Celsius)" : 61
+		},
+		"Virtual Drives" : 2,
+		"VD LIST" : [
+			{
+				"DG/VD" : "0/0",
+				"TYPE" : "RAID1",
+				"State" : "Optl",
+				"Access" : "RW",
+				"Consist" : "Yes",
+				"Cache" : "RWBD",
+				"Cac" : "-",
+				"sCC" : "ON",
+				"Size" : "278.875 GB",
+				"Name" : "os"
+			},
+			{
+				"DG/VD" : "1/1",
+				"TYPE" : "RAID5",
+				"State" : "Dgrd",
+				"Access" : "RW",
+				"Consist" : "No",
+				"Cache" : "RWBD",
+				"Cac" : "-",
+				"sCC" : "ON",
+				"Size" : "1.089 TB",
+				"Name" : "data"
+			}
+		],
+		"Physical Drives" : 6,
+		"PD LIST" : [
+			{
+				"EID:Slt" : "32:0",
+				"DID" : 0,
+				"State" : "Onln",
+				"DG" : 0,
+				"Size" : "278.875 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST300MM0048     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:1",
+				"DID" : 1,
+				"State" : "Onln",
+				"DG" : 0,
+				"Size" : "278.875 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST300MM0048     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:2",
+				"DID" : 2,
+				"State" : "Onln",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:3",
+				"DID" : 3,
+				"State" : "Onln",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:4",
+				"DID" : 4,
+				"State" : "Rbld",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:5",
+				"DID" : 5,
+				"State" : "UGood",
+				"DG" : "-",
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"Cachevault_Info" : [
+			{
+				"Model" : "CVPM02",
+				"State" : "Optimal",
+				"Temp" : "27C",
+				"Mode" : "-",
+				"MfgDate" : "2018/06/29"
+			}
+		]
+	}
+}
+]
+}
+`
+
+var storcliCallVallShowAll1 = `
+{
+"Controllers":[
+{
+	"Command Status" : {
+		"CLI Version" : "007.1017.0000.0000 May 10, 2019",
+		"Operating system" : "Linux 4.18.0-305.el8.x86_64",
+		"Controller" : 0,
+		"Status" : "Success",
+		"Description" : "None"
+	},
+	"Response Data" : {
+		"/c0/v0" : [
+			{
+				"DG/VD" : "0/0",
+				"TYPE" : "RAID1",
+				"State" : "Optl",
+				"Access" : "RW",
+				"Consist" : "Yes",
+				"Cache" : "RWBD",
+				"Cac" : "-",
+				"sCC" : "ON",
+				"Size" : "278.875 GB",
+				"Name" : "os"
+			}
+		],
+		"PDs for VD 0" : [
+			{
+				"EID:Slt" : "32:0",
+				"DID" : 0,
+				"State" : "Onln",
+				"DG" : 0,
+				"Size" : "278.875 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST300MM0048     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:1",
+				"DID" : 1,
+				"State" : "Onln",
+				"DG" : 0,
+				"Size" : "278.875 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST300MM0048     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"VD0 Properties" : {
+			"Strip Size" : "64 KB",
+			"Number of Blocks" : 584843264,
+			"VD has Emulated PD" : "No",
+			"Span Depth" : 1,
+			"Number of Drives Per Span" : 2,
+			"Write Cache(initial setting)" : "WriteBack",
+			"Disk Cache Policy" : "Disk's Default",
+			"Encryption" : "None",
+			"Data Protection" : "Disabled",
+			"Active Operations" : "None",
+			"Exposed to OS" : "Yes",
+			"OS Drive Name" : "/dev/sda",
+			"Creation Date" : "12-08-2018",
+			"Creation Time" : "02:13:50 PM",
+			"Emulation type" : "default",
+			"Cachebypass size" : "Cachebypass-64k",
+			"Cachebypass Mode" : "Cachebypass Intelligent",
+			"Is LD Ready for OS Requests" : "Yes",
+			"SCSI NAA Id" : "6d0946606ad6c7002314c4f20b0c6c35"
+		},
+		"/c0/v1" : [
+			{
+				"DG/VD" : "1/1",
+				"TYPE" : "RAID5",
+				"State" : "Dgrd",
+				"Access" : "RW",
+				"Consist" : "No",
+				"Cache" : "RWBD",
+				"Cac" : "-",
+				"sCC" : "ON",
+				"Size" : "1.089 TB",
+				"Name" : "data"
+			}
+		],
+		"PDs for VD 1" : [
+			{
+				"EID:Slt" : "32:2",
+				"DID" : 2,
+				"State" : "Onln",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:3",
+				"DID" : 3,
+				"State" : "Onln",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			},
+			{
+				"EID:Slt" : "32:4",
+				"DID" : 4,
+				"State" : "Rbld",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"VD1 Properties" : {
+			"Strip Size" : "64 KB",
+			"Number of Blocks" : 2341795840,
+			"VD has Emulated PD" : "No",
+			"Span Depth" : 1,
+			"Number of Drives Per Span" : 3,
+			"Write Cache(initial setting)" : "WriteBack",
+			"Disk Cache Policy" : "Disk's Default",
+			"Encryption" : "None",
+			"Data Protection" : "Disabled",
+			"Active Operations" : "None",
+			"Exposed to OS" : "Yes",
+			"OS Drive Name" : "/dev/sdb",
+			"Creation Date" : "12-08-2018",
+			"Creation Time" : "02:15:07 PM",
+			"Emulation type" : "default",
+			"Cachebypass size" : "Cachebypass-64k",
+			"Cachebypass Mode" : "Cachebypass Intelligent",
+			"Is LD Ready for OS Requests" : "Yes",
+			"SCSI NAA Id" : "6d0946606ad6c7002314c52b0e7a1f4b"
+		}
+	}
+}
+]
+}
+`
+
+var storcliCallEallSallShowAll1 = `
+{
+"Controllers":[
+{
+	"Command Status" : {
+		"CLI Version" : "007.1017.0000.0000 May 10, 2019",
+		"Operating system" : "Linux 4.18.0-305.el8.x86_64",
+		"Controller" : 0,
+		"Status" : "Success",
+		"Description" : "Show Drive Information Succeeded."
+	},
+	"Response Data" : {
+		"Drive /c0/e32/s0" : [
+			{
+				"EID:Slt" : "32:0",
+				"DID" : 0,
+				"State" : "Onln",
+				"DG" : 0,
+				"Size" : "278.875 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST300MM0048     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"Drive /c0/e32/s0 - Detailed Information" : {
+			"Drive /c0/e32/s0 State" : {
+				"Shield Counter" : 0,
+				"Media Error Count" : 0,
+				"Other Error Count" : 0,
+				"Drive Temperature" : " 33C (91.40 F)",
+				"Predictive Failure Count" : 0,
+				"S.M.A.R.T alert flagged by drive" : "No"
+			},
+			"Drive /c0/e32/s0 Device attributes" : {
+				"SN" : "S0K2HM3R        ",
+				"Manufacturer Id" : "SEAGATE ",
+				"Model Number" : "ST300MM0048     ",
+				"NAND Vendor" : "NA",
+				"WWN" : "5000C5009E2F1A5C",
+				"Firmware Revision" : "N004    ",
+				"Raw size" : "279.396 GB [0x22ecb25c Sectors]",
+				"Coerced size" : "278.875 GB [0x22dc0000 Sectors]",
+				"Non Coerced size" : "278.896 GB [0x22dcb25c Sectors]",
+				"Device Speed" : "12.0Gb/s",
+				"Link Speed" : "12.0Gb/s",
+				"NCQ setting" : "N/A",
+				"Write Cache" : "N/A",
+				"Logical Sector Size" : "512B",
+				"Physical Sector Size" : "512B",
+				"Connector Name" : "00 "
+			}
+		},
+		"Drive /c0/e32/s1" : [
+			{
+				"EID:Slt" : "32:1",
+				"DID" : 1,
+				"State" : "Onln",
+				"DG" : 0,
+				"Size" : "278.875 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST300MM0048     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"Drive /c0/e32/s1 - Detailed Information" : {
+			"Drive /c0/e32/s1 State" : {
+				"Shield Counter" : 0,
+				"Media Error Count" : 0,
+				"Other Error Count" : 2,
+				"Drive Temperature" : " 35C (95.00 F)",
+				"Predictive Failure Count" : 3,
+				"S.M.A.R.T alert flagged by drive" : "No"
+			},
+			"Drive /c0/e32/s1 Device attributes" : {
+				"SN" : "S0K2HL9T        ",
+				"Manufacturer Id" : "SEAGATE ",
+				"Model Number" : "ST300MM0048     ",
+				"NAND Vendor" : "NA",
+				"WWN" : "5000C5009E2E83B0",
+				"Firmware Revision" : "N004    ",
+				"Device Speed" : "12.0Gb/s",
+				"Link Speed" : "12.0Gb/s"
+			}
+		},
+		"Drive /c0/e32/s2" : [
+			{
+				"EID:Slt" : "32:2",
+				"DID" : 2,
+				"State" : "Onln",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"Drive /c0/e32/s2 - Detailed Information" : {
+			"Drive /c0/e32/s2 State" : {
+				"Shield Counter" : 0,
+				"Media Error Count" : 0,
+				"Other Error Count" : 0,
+				"Drive Temperature" : " 31C (87.80 F)",
+				"Predictive Failure Count" : 0,
+				"S.M.A.R.T alert flagged by drive" : "No"
+			},
+			"Drive /c0/e32/s2 Device attributes" : {
+				"SN" : "W0M1Q8HG        ",
+				"WWN" : "5000C500B40D5E53",
+				"Firmware Revision" : "N003    "
+			}
+		},
+		"Drive /c0/e32/s3" : [
+			{
+				"EID:Slt" : "32:3",
+				"DID" : 3,
+				"State" : "Onln",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"Drive /c0/e32/s3 - Detailed Information" : {
+			"Drive /c0/e32/s3 State" : {
+				"Shield Counter" : 0,
+				"Media Error Count" : 0,
+				"Other Error Count" : 0,
+				"Predictive Failure Count" : 0,
+				"S.M.A.R.T alert flagged by drive" : "No"
+			},
+			"Drive /c0/e32/s3 Device attributes" : {
+				"SN" : "W0M1Q9A4        ",
+				"Manufacturer Id" : "SEAGATE ",
+				"Model Number" : "ST600MM0208     ",
+				"NAND Vendor" : "NA",
+				"Device Speed" : "12.0Gb/s",
+				"Link Speed" : "12.0Gb/s"
+			}
+		},
+		"Drive /c0/e32/s4" : [
+			{
+				"EID:Slt" : "32:4",
+				"DID" : 4,
+				"State" : "Rbld",
+				"DG" : 1,
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"Drive /c0/e32/s4 - Detailed Information" : {
+			"Drive /c0/e32/s4 State" : {
+				"Shield Counter" : 0,
+				"Media Error Count" : 0,
+				"Other Error Count" : 0,
+				"Drive Temperature" : " 30C (86.00 F)",
+				"Predictive Failure Count" : 0,
+				"S.M.A.R.T alert flagged by drive" : "No"
+			},
+			"Drive /c0/e32/s4 Device attributes" : {
+				"SN" : "W0M1R0XK        ",
+				"WWN" : "5000C500B40E1C9B",
+				"Firmware Revision" : "N003    "
+			}
+		},
+		"Drive /c0/e32/s5" : [
+			{
+				"EID:Slt" : "32:5",
+				"DID" : 5,
+				"State" : "UGood",
+				"DG" : "-",
+				"Size" : "558.375 GB",
+				"Intf" : "SAS",
+				"Med" : "HDD",
+				"SED" : "N",
+				"PI" : "N",
+				"SeSz" : "512B",
+				"Model" : "ST600MM0208     ",
+				"Sp" : "U",
+				"Type" : "-"
+			}
+		],
+		"Drive /c0/e32/s5 - Detailed Information" : {
+			"Drive /c0/e32/s5 State" : {
+				"Shield Counter" : 0,
+				"Media Error Count" : 0,
+				"Other Error Count" : 0,
+				"Drive Temperature" : " 29C (84.20 F)",
+				"Predictive Failure Count" : 0,
+				"S.M.A.R.T alert flagged by drive" : "No"
+			},
+			"Drive /c0/e32/s5 Device attributes" : {
+				"SN" : "W0M1QB2T        ",
+				"WWN" : "5000C500B40E2D07",
+				"Firmware Revision" : "N003    "
+			}
+		}
+	}
+}
+]
+}
+`
+
+var storcliNoControllers = `
+{
+"Controllers":[
+{
+	"Command Status" : {
+		"CLI Version" : "007.1017.0000.0000 May 10, 2019",
+		"Operating system" : "Linux 4.18.0-305.el8.x86_64",
+		"Status Code" : 0,
+		"Status" : "Failure",
+		"Description" : "None",
+		"Detailed Status" : [
+			{
+				"Ctrl" : 0,
+				"Status" : "Failure",
+				"ErrMsg" : "Controller 0 not found"
+			}
+		]
+	}
+}
+]
+}
+`
+
+func TestParseStorcliVersion(t *testing.T) {
+	vers, err := devmon.ParseStorcliVersion(storcliVersion1)
+	assert.NoError(t, err)
+	assert.Equal(t, vers, "007.1017.0000.0000 May 10, 2019")
+
+	_, err = devmon.ParseStorcliVersion("")
+	assert.Error(t, err)
+}
+
+func TestParseStorcliControllers(t *testing.T) {
+	ctrls, err := devmon.ParseStorcliControllers(storcliCallShowAll1,
+		storcliCallVallShowAll1, storcliCallEallSallShowAll1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ctrls), 1)
+
+	ctrl := ctrls[0]
+	assert.Equal(t, ctrl.ID, "0")
+	assert.Equal(t, ctrl.Model, "PERC H730P Mini")
+	assert.Equal(t, ctrl.Serial, "8AF00VK")
+	assert.Equal(t, ctrl.Firmware, "4.300.00-8364")
+	assert.Equal(t, ctrl.Status, "OK")
+	assert.Equal(t, ctrl.BatteryStatus, "OK")
+	assert.Equal(t, ctrl.Temperature, int64(61))
+	assert.Equal(t, len(ctrl.Arrays), 2)
+
+	arr0 := ctrl.Arrays[0]
+	assert.Equal(t, arr0.Name, "0")
+	assert.Equal(t, arr0.Status, "OK")
+	assert.Equal(t, len(arr0.PhysicalDrives), 2)
+	assert.Equal(t, len(arr0.LogicalDrives), 1)
+
+	ld0 := arr0.LogicalDrives[0]
+	assert.Equal(t, ld0.ID, "0")
+	assert.Equal(t, ld0.DiskName, "/dev/sda")
+	assert.Equal(t, ld0.UniqueID, "6d0946606ad6c7002314c4f20b0c6c35")
+	assert.Equal(t, ld0.RaidLevel, "RAID1")
+	assert.Equal(t, ld0.Status, "OK")
+	assert.Greater(t, ld0.SizeBytes, uint64(0))
+	assert.Equal(t, len(ld0.PhysicalDrives), 2)
+
+	pd0 := ld0.PhysicalDrives[0]
+	assert.Equal(t, pd0.ID, "/c0/e32/s0")
+	assert.Equal(t, pd0.Box, "32")
+	assert.Equal(t, pd0.Bay, "0")
+	assert.Equal(t, pd0.Model, "ST300MM0048")
+	assert.Equal(t, pd0.Serial, "S0K2HM3R")
+	assert.Equal(t, pd0.Firmware, "N004")
+	assert.Equal(t, pd0.UniqueID, "5000C5009E2F1A5C")
+	assert.Equal(t, pd0.MediaType, "HDD")
+	assert.Equal(t, pd0.Interface, "SAS")
+	assert.Equal(t, pd0.Status, "OK")
+	assert.Equal(t, pd0.TempCurr, int64(33))
+	assert.Equal(t, pd0.PowerHours, int64(-1))
+
+	pd1 := ld0.PhysicalDrives[1]
+	assert.Equal(t, pd1.Status, "Predictive Failure")
+
+	arr1 := ctrl.Arrays[1]
+	assert.Equal(t, arr1.Name, "1")
+	assert.Equal(t, arr1.Status, "Degraded")
+	ld1 := arr1.LogicalDrives[0]
+	assert.Equal(t, ld1.DiskName, "/dev/sdb")
+	assert.Equal(t, ld1.Status, "Degraded")
+	assert.Equal(t, len(ld1.PhysicalDrives), 3)
+	assert.Equal(t, ld1.PhysicalDrives[2].Status, "Rebuilding")
+
+	// drive without temperature, WWN and firmware revision
+	pd3 := ld1.PhysicalDrives[1]
+	assert.Equal(t, pd3.ID, "/c0/e32/s3")
+	assert.Equal(t, pd3.Serial, "W0M1Q9A4")
+	assert.Equal(t, pd3.UniqueID, "")
+	assert.Equal(t, pd3.Firmware, "")
+	assert.Equal(t, pd3.TempCurr, int64(-1))
+	assert.Equal(t, pd3.Status, "OK")
+
+	bdi := devmon.BlkdevInfo{Name: "sdb", WWN: "6d0946606ad6c7002314c52b0e7a1f4b"}
+	_, ld := devmon.LookupRaidLogicalDrive(ctrls, &bdi)
+	assert.NotNil(t, ld)
+	assert.Equal(t, ld.RaidLevel, "RAID5")
+}
+
+func TestParseStorcliNoControllers(t *testing.T) {
+	ctrls, err := devmon.ParseStorcliControllers(storcliNoControllers,
+		storcliNoControllers, storcliNoControllers)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ctrls), 0)
+
+	_, err = devmon.ParseStorcliControllers("{", "", "")
+	assert.Error(t, err)
+}
@@ -2,7 +2,11 @@
 package devmon
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -16,10 +20,10 @@ const (
 )
 
 func NewSysFS() *SysFS {
-	return newSysFS(sysDefaultMountPoint)
+	return NewSysFSAt(sysDefaultMountPoint)
 }
 
-func newSysFS(prefix string) *SysFS {
+func NewSysFSAt(prefix string) *SysFS {
 	return &SysFS{
 		PseudoFS: PseudoFS{
 			Prefix: prefix,
@@ -50,6 +54,90 @@ func (sysfs *SysFS) IsBlock(name string) (bool, error) {
 	return sysfs.IsDir("block", name)
 }
 
+// CgroupsPressureIO parses io.pressure of each top-level control group of
+// cgroup v2 hierarchy, mounted at /sys/fs/cgroup (or /sys/fs/cgroup/unified,
+// in hybrid mode). Control groups without I/O pressure info are ignored.
+func (sysfs *SysFS) CgroupsPressureIO() (map[string]*SysPressure, error) {
+	ret := map[string]*SysPressure{}
+	cgfs := sysfs.Sub(filepath.Join("fs", "cgroup"))
+	if unified, _ := cgfs.IsDir("unified"); unified {
+		cgfs = sysfs.Sub(filepath.Join("fs", "cgroup", "unified"))
+	}
+	subs, err := cgfs.ReadDir()
+	if err != nil {
+		return ret, err
+	}
+	for _, sub := range subs {
+		cgroup := filepath.Base(sub)
+		if isdir, _ := cgfs.IsDir(cgroup); !isdir {
+			continue
+		}
+		psi, err := cgfs.ReadFilePressure(cgroup, "io.pressure")
+		if err != nil {
+			continue
+		}
+		ret[cgroup] = psi
+	}
+	return ret, nil
+}
+
+// BlockDeviceOf resolves the name of the whole-disk block device which owns
+// device number <major>:<minor>, using /sys/dev/block/<major>:<minor> link.
+// For partitions, the name of parent device is returned.
+func (sysfs *SysFS) BlockDeviceOf(major, minor uint32) (string, error) {
+	devpath := sysfs.resolvePath([]string{"dev", "block", fmt.Sprintf("%d:%d", major, minor)})
+	target, err := os.Readlink(devpath)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(target)
+	if _, err := os.Stat(filepath.Join(devpath, "partition")); err == nil {
+		name = filepath.Base(filepath.Dir(target))
+	}
+	return name, nil
+}
+
+// BlockDeviceNumberOf resolves the device number of block device by its path
+// in /dev, as found in mountinfo's source of file-systems which do not report
+// a real device number (e.g. btrfs). Device-mapper names (/dev/mapper/<name>)
+// are resolved via /sys/block/dm-*/dm/name.
+func (sysfs *SysFS) BlockDeviceNumberOf(devpath string) (uint32, uint32, error) {
+	name := filepath.Base(devpath)
+	if filepath.Dir(devpath) == "/dev/mapper" {
+		dmname, err := sysfs.deviceMapperDevice(name)
+		if err != nil {
+			return 0, 0, err
+		}
+		name = dmname
+	}
+	dat, err := sysfs.ReadFileTrim("class", "block", name, "dev")
+	if err != nil {
+		return 0, 0, err
+	}
+	var major, minor uint32
+	if _, err := fmt.Sscanf(dat, "%d:%d", &major, &minor); err != nil {
+		return 0, 0, fmt.Errorf("bad device number of %s: %q", devpath, dat)
+	}
+	return major, minor, nil
+}
+
+func (sysfs *SysFS) deviceMapperDevice(dmname string) (string, error) {
+	devs, err := sysfs.ListBlockDevices()
+	if err != nil {
+		return "", err
+	}
+	for _, devpath := range devs {
+		dev := filepath.Base(devpath)
+		if !strings.HasPrefix(dev, "dm-") {
+			continue
+		}
+		if name, err := sysfs.ReadFileTrim("block", dev, "dm", "name"); err == nil && name == dmname {
+			return dev, nil
+		}
+	}
+	return "", fmt.Errorf("no such device-mapper device: %s", dmname)
+}
+
 // BlockStat parses /sys/block/<device>/stat
 // https://www.kernel.org/doc/Documentation/block/stat.txt
 func (sysfs *SysFS) BlockStat(dev string) (*BlkdevIOStat, error) {
@@ -236,5 +324,103 @@ func (sysfs *SysFS) BlkdevInfo(dev string) (*BlkdevInfo, error) {
 	if ret.Readonly, err = pfs.ReadFileAsBool("ro"); err != nil {
 		ret.Readonly = false
 	}
+	sysfs.blkdevIdents(pfs, ret)
 	return ret, nil
 }
+
+// blkdevIdents fills the persistent identifiers of a block device: WWID as
+// reported by the kernel, a normalized WWN (from WWID or VPD page 0x83) and
+// the device's serial number (from sysfs or VPD page 0x80).
+func (sysfs *SysFS) blkdevIdents(pfs *PseudoFS, bdi *BlkdevInfo) {
+	var err error
+	if bdi.WWID, err = pfs.ReadFileTrim("device", "wwid"); err != nil {
+		if bdi.WWID, err = pfs.ReadFileTrim("wwid"); err != nil {
+			bdi.WWID = ""
+		}
+	}
+	bdi.WWN = NormalizeWWN(bdi.WWID)
+	if vpd, err := pfs.ReadFile("device", "vpd_pg83"); err == nil {
+		if wwn, err := ParseVpdPage83([]byte(vpd)); err == nil {
+			bdi.WWN = wwn
+		}
+	}
+	if bdi.Serial, err = pfs.ReadFileTrim("device", "serial"); err != nil {
+		bdi.Serial = ""
+	}
+	if bdi.Serial == "" {
+		if vpd, err := pfs.ReadFile("device", "vpd_pg80"); err == nil {
+			bdi.Serial, _ = ParseVpdPage80([]byte(vpd))
+		}
+	}
+}
+
+// ParseVpdPage80 extracts the unit serial number from raw SCSI VPD page 0x80
+// (as exported by /sys/block/<dev>/device/vpd_pg80).
+func ParseVpdPage80(dat []byte) (string, error) {
+	if len(dat) < 4 || dat[1] != 0x80 {
+		return "", errors.New("malformed vpd page 0x80")
+	}
+	plen := int(binary.BigEndian.Uint16(dat[2:4]))
+	if len(dat) < 4+plen {
+		return "", errors.New("truncated vpd page 0x80")
+	}
+	return strings.TrimSpace(string(dat[4 : 4+plen])), nil
+}
+
+// ParseVpdPage83 extracts a world-wide name from raw SCSI VPD page 0x83
+// (device identification). NAA designators of the logical unit are
+// preferred over EUI-64 ones. The result is formatted as lower-case hex.
+// See: SCSI Primary Commands (SPC-4), section 7.8.6
+func ParseVpdPage83(dat []byte) (string, error) {
+	const (
+		desigEUI64 = 0x2
+		desigNAA   = 0x3
+	)
+	if len(dat) < 4 || dat[1] != 0x83 {
+		return "", errors.New("malformed vpd page 0x83")
+	}
+	plen := int(binary.BigEndian.Uint16(dat[2:4]))
+	if len(dat) < 4+plen {
+		return "", errors.New("truncated vpd page 0x83")
+	}
+	eui := ""
+	for pos := 4; pos+4 <= 4+plen; {
+		assoc := (dat[pos+1] >> 4) & 0x3
+		dtype := dat[pos+1] & 0xf
+		dlen := int(dat[pos+3])
+		if pos+4+dlen > len(dat) {
+			break
+		}
+		desig := dat[pos+4 : pos+4+dlen]
+		if assoc == 0 {
+			switch dtype {
+			case desigNAA:
+				return hex.EncodeToString(desig), nil
+			case desigEUI64:
+				if eui == "" {
+					eui = hex.EncodeToString(desig)
+				}
+			}
+		}
+		pos += 4 + dlen
+	}
+	if eui == "" {
+		return "", errors.New("no wwn in vpd page 0x83")
+	}
+	return eui, nil
+}
+
+// NormalizeWWN converts the various textual forms of a world-wide name
+// ("naa.6005...", "wwn-0x6005...", "0x6005...", "6005...") into plain
+// lower-case hex, so that identifiers from different sources are comparable.
+// Non-WWN identifiers (e.g. "t10.ATA ...") yield an empty string.
+func NormalizeWWN(s string) string {
+	wwn := strings.ToLower(strings.TrimSpace(s))
+	for _, pref := range []string{"wwn-", "naa.", "eui.", "0x"} {
+		wwn = strings.TrimPrefix(wwn, pref)
+	}
+	if len(wwn) == 0 || strings.Trim(wwn, "0123456789abcdef") != "" {
+		return ""
+	}
+	return wwn
+}
@@ -35,3 +35,95 @@ func TestSysfsBlkdev(t *testing.T) {
 		assert.GreaterOrEqual(t, int64(bdi.Size), int64(0))
 	}
 }
+
+func TestParseVpdPage83(t *testing.T) {
+	vpd := []byte{
+		0x00, 0x83, 0x00, 0x20,
+		// T10 vendor id (ascii)
+		0x02, 0x01, 0x00, 0x08,
+		'H', 'P', ' ', ' ', ' ', ' ', ' ', ' ',
+		// NAA (binary), associated with logical unit
+		0x01, 0x03, 0x00, 0x10,
+		0x60, 0x05, 0x08, 0xb1, 0x00, 0x1c, 0x90, 0xdb,
+		0x4a, 0x1f, 0xdc, 0xbc, 0xcb, 0x74, 0x4f, 0x14,
+	}
+	wwn, err := devmon.ParseVpdPage83(vpd)
+	assert.NoError(t, err)
+	assert.Equal(t, wwn, "600508b1001c90db4a1fdcbccb744f14")
+
+	_, err = devmon.ParseVpdPage83(vpd[:4+12])
+	assert.Error(t, err)
+	_, err = devmon.ParseVpdPage83([]byte{0x00, 0x80, 0x00, 0x00})
+	assert.Error(t, err)
+}
+
+func TestParseVpdPage80(t *testing.T) {
+	vpd := []byte{0x00, 0x80, 0x00, 0x08, ' ', 'P', 'D', 'N', 'N', 'K', '0', ' '}
+	serial, err := devmon.ParseVpdPage80(vpd)
+	assert.NoError(t, err)
+	assert.Equal(t, serial, "PDNNK0")
+}
+
+func TestNormalizeWWN(t *testing.T) {
+	wwn := "600508b1001c90db4a1fdcbccb744f14"
+	assert.Equal(t, devmon.NormalizeWWN("naa.600508b1001c90db4a1fdcbccb744f14"), wwn)
+	assert.Equal(t, devmon.NormalizeWWN("wwn-0x600508b1001c90db4a1fdcbccb744f14"), wwn)
+	assert.Equal(t, devmon.NormalizeWWN("600508B1001C90DB4A1FDCBCCB744F14"), wwn)
+	assert.Equal(t, devmon.NormalizeWWN("t10.ATA     QEMU HARDDISK"), "")
+	assert.Equal(t, devmon.NormalizeWWN(""), "")
+}
+
+func TestSysfsNvmeControllers(t *testing.T) {
+	sysfs := devmon.NewSysFS()
+	ncis, err := sysfs.NvmeControllers()
+	assert.NoError(t, err)
+	for _, nci := range ncis {
+		assert.Greater(t, len(nci.Name), 0)
+		assert.Greater(t, len(nci.Model), 0)
+		for _, ns := range nci.Namespaces {
+			isblk, _ := sysfs.IsBlock(ns)
+			assert.True(t, isblk)
+		}
+	}
+}
+
+func TestSysfsNvmeControllersFixture(t *testing.T) {
+	ncis, err := devmon.NewSysFSAt("testdata/nvme/sys").NvmeControllers()
+	assert.NoError(t, err)
+	assert.Equal(t, []devmon.NvmeControllerInfo{
+		{
+			Name:        "nvme0",
+			Model:       "SAMSUNG MZQL2960HCJR-00A07",
+			Serial:      "S64FNE0R802315",
+			FirmwareRev: "GDC5602Q",
+			State:       "live",
+			Transport:   "pcie",
+			Cntlid:      6,
+			Namespaces:  []string{"nvme0n1", "nvme0n2"},
+		},
+		{
+			Name:        "nvme1",
+			Model:       "NetApp ONTAP Controller",
+			Serial:      "81LGgBUqsI1AAAAAAAAB",
+			FirmwareRev: "FFFFFFFF",
+			State:       "live",
+			Transport:   "tcp",
+			Cntlid:      1,
+			Namespaces:  []string{"nvme1n1", "nvme1n2"},
+		},
+		{
+			Name:        "nvme2",
+			Model:       "NetApp ONTAP Controller",
+			Serial:      "81LGgBUqsI1AAAAAAAAB",
+			FirmwareRev: "FFFFFFFF",
+			State:       "connecting",
+			Transport:   "tcp",
+			Cntlid:      2,
+			Namespaces:  []string{"nvme1n1", "nvme1n2"},
+		},
+	}, ncis)
+
+	ncis, err = devmon.NewSysFSAt("testdata/nonexistent").NvmeControllers()
+	assert.NoError(t, err)
+	assert.Empty(t, ncis)
+}
@@ -14,6 +14,29 @@ type NodeInfo struct {
 	HostIP []string `json:"hostip"`
 }
 
+// SysPressureStat represents a single line of pressure stall information
+type SysPressureStat struct {
+	Avg10     float64 `json:"avg10"`
+	Avg60     float64 `json:"avg60"`
+	Avg300    float64 `json:"avg300"`
+	TotalUSec uint64  `json:"total"`
+}
+
+// SysPressure represents pressure stall information (PSI) of a resource; Full
+// is nil when the "full" line is not reported (e.g. CPU PSI before Linux 5.13).
+// https://www.kernel.org/doc/html/latest/accounting/psi.html
+type SysPressure struct {
+	Some SysPressureStat  `json:"some"`
+	Full *SysPressureStat `json:"full,omitempty"`
+}
+
+// SysStatfs represents file-system statistics, as reported by statfs(2)
+type SysStatfs struct {
+	SizeBytes  uint64 `json:"sizebytes"`
+	FreeBytes  uint64 `json:"freebytes"`
+	AvailBytes uint64 `json:"availbytes"`
+}
+
 type SysLoadAvg struct {
 	Load1  float64 `json:"load1"`
 	Load5  float64 `json:"load5"`
@@ -23,13 +46,41 @@ type SysLoadAvg struct {
 // BlkdevInfo contains collection of raw information under /sys/block/<disk>/...
 // https://www.kernel.org/doc/Documentation/ABI/testing/sysfs-block
 type BlkdevInfo struct {
-	Major    uint32 `json:"major"`
-	Minor    uint32 `json:"minor"`
-	Name     string `json:"name"`
-	Size     uint64 `json:"size"`
-	Vendor   string `json:"vendor"`
-	Model    string `json:"model"`
-	Readonly bool   `json:"readonly"`
+	Major    uint32            `json:"major"`
+	Minor    uint32            `json:"minor"`
+	Name     string            `json:"name"`
+	Size     uint64            `json:"size"`
+	Vendor   string            `json:"vendor"`
+	Model    string            `json:"model"`
+	Readonly bool              `json:"readonly"`
+	WWID     string            `json:"wwid"`
+	WWN      string            `json:"wwn"`
+	Serial   string            `json:"serial"`
+	ByID     []string          `json:"byid"`
+	ByPath   []string          `json:"bypath"`
+	Udev     map[string]string `json:"udev"`
+}
+
+// MountInfo represents a single entry of /proc/<pid>/mountinfo
+// https://www.kernel.org/doc/Documentation/filesystems/proc.txt
+type MountInfo struct {
+	MountID    uint32 `json:"mountid"`
+	ParentID   uint32 `json:"parentid"`
+	Major      uint32 `json:"major"`
+	Minor      uint32 `json:"minor"`
+	Root       string `json:"root"`
+	MountPoint string `json:"mountpoint"`
+	Options    string `json:"options"`
+	FSType     string `json:"fstype"`
+	Source     string `json:"source"`
+}
+
+// BlkdevMountInfo associates a mounted file-system with its underlying block
+// device (whole disk, in case of partitions).
+type BlkdevMountInfo struct {
+	MountInfo
+	SysStatfs
+	DeviceName string `json:"devname"`
 }
 
 type BlkdevID struct {
@@ -97,3 +148,16 @@ type BlkdevQueueStats struct {
 	Zoned                string `json:"zoned"`
 	ZoneWriteGranularity int    `json:"zonewritegranularity"`
 }
+
+// NvmeControllerInfo contains collection of raw information under
+// /sys/class/nvme/<ctrl>/...
+type NvmeControllerInfo struct {
+	Name        string   `json:"name"`
+	Model       string   `json:"model"`
+	Serial      string   `json:"serial"`
+	FirmwareRev string   `json:"firmwarerev"`
+	State       string   `json:"state"`
+	Transport   string   `json:"transport"`
+	Cntlid      uint32   `json:"cntlid"`
+	Namespaces  []string `json:"namespaces"`
+}
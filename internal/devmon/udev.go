@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// UdevFS provides access to udev's run-time database of devices properties,
+// under /run/udev/data
+type UdevFS struct {
+	PseudoFS
+}
+
+const (
+	udevDefaultDataDir = "/run/udev/data"
+)
+
+func NewUdevFS(hostRoot string) *UdevFS {
+	return &UdevFS{
+		PseudoFS: PseudoFS{
+			Prefix: filepath.Join(hostRoot, udevDefaultDataDir),
+		},
+	}
+}
+
+// BlockDeviceProperties returns the properties ("E:" records) of the block
+// device <major>:<minor> from /run/udev/data/b<major>:<minor>
+func (udevfs *UdevFS) BlockDeviceProperties(major, minor uint32) (map[string]string, error) {
+	lines, err := udevfs.ReadFileLines(fmt.Sprintf("b%d:%d", major, minor))
+	if err != nil {
+		return map[string]string{}, err
+	}
+	return udevfs.parseProperties(lines), nil
+}
+
+func (udevfs *UdevFS) parseProperties(lines []string) map[string]string {
+	ret := map[string]string{}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "E:") {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(line, "E:"), "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			continue
+		}
+		ret[kv[0]] = kv[1]
+	}
+	return ret
+}
@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"testing"
+
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUdevBlockDeviceProperties(t *testing.T) {
+	udevfs := devmon.NewUdevFS("testdata/udev")
+	props, err := udevfs.BlockDeviceProperties(8, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, len(props), 8)
+	assert.Equal(t, props["ID_SERIAL"], "3600508b1001c90db4a1fdcbccb744f14")
+	assert.Equal(t, props["ID_PATH"], "pci-0000:03:00.0-scsi-0:1:0:0")
+	assert.Equal(t, props["ID_MODEL"], "LOGICAL_VOLUME")
+	_, ok := props["ID_FS_TYPE"]
+	assert.False(t, ok)
+
+	_, err = udevfs.BlockDeviceProperties(8, 16)
+	assert.Error(t, err)
+}
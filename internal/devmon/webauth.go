@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// tokenReviewCacheTTL is the duration for which a successful token review
+	// (and access review) is cached, to avoid API requests on each scrape
+	tokenReviewCacheTTL = 2 * time.Minute
+	tokenReviewTimeout  = 10 * time.Second
+)
+
+// errNotAuthenticated is returned by token review of a rejected token, as
+// opposed to a failure of the review itself (e.g. unreachable API server).
+var errNotAuthenticated = errors.New("token not authenticated")
+
+// kitLogger adapts logr.Logger to go-kit's Logger interface, as expected by
+// exporter-toolkit; go-kit's "msg", "level" and "err" keys are mapped to
+// logr's message, error level and error, respectively.
+type kitLogger struct {
+	log logr.Logger
+}
+
+func (kl kitLogger) Log(keyvals ...interface{}) error {
+	msg := "web"
+	kvs := []interface{}{}
+	isErr := false
+	var err error
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		val := keyvals[i+1]
+		switch key := fmt.Sprint(keyvals[i]); key {
+		case "msg":
+			msg = fmt.Sprint(val)
+		case "level":
+			isErr = fmt.Sprint(val) == "error"
+		case "err":
+			err, _ = val.(error)
+			isErr = true
+		default:
+			kvs = append(kvs, key, val)
+		}
+	}
+	if isErr {
+		kl.log.Error(err, msg, kvs...)
+	} else {
+		kl.log.Info(msg, kvs...)
+	}
+	return nil
+}
+
+// tokenReviewer authenticates HTTP requests by their bearer token, using
+// Kubernetes' TokenReview API, and authorizes the requested (non-resource)
+// path using SubjectAccessReview API, in the manner of kube-rbac-proxy.
+type tokenReviewer struct {
+	log   logr.Logger
+	cset  kubernetes.Interface
+	mtx   sync.Mutex
+	cache map[string]time.Time
+}
+
+func newTokenReviewer(log logr.Logger, cset kubernetes.Interface) *tokenReviewer {
+	return &tokenReviewer{
+		log:   log,
+		cset:  cset,
+		cache: map[string]time.Time{},
+	}
+}
+
+// TokenReviewHandler returns handler which serves only requests with bearer
+// token which is authenticated and authorized (for the request's path) by
+// Kubernetes API.
+func TokenReviewHandler(log logr.Logger, cset kubernetes.Interface,
+	handler http.Handler) http.Handler {
+	return newTokenReviewer(log, cset).wrap(handler)
+}
+
+func (tr *tokenReviewer) wrap(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="hpessa-exporter"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		verb := strings.ToLower(r.Method)
+		allowed, err := tr.review(r.Context(), token, r.URL.Path, verb, time.Now())
+		if errors.Is(err, errNotAuthenticated) {
+			tr.log.Info("token rejected", "path", r.URL.Path, "reason", err.Error())
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			tr.log.Error(err, "failed to review token", "path", r.URL.Path)
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// review returns whether the token's user may access path with verb, or error
+// (errNotAuthenticated if the token is rejected). Only allowed access is
+// cached, so that failed reviews are retried on next request.
+func (tr *tokenReviewer) review(ctx context.Context, token, path, verb string,
+	now time.Time) (bool, error) {
+	key := verb + " " + path + " " + token
+	if tr.cached(key, now) {
+		return true, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, tokenReviewTimeout)
+	defer cancel()
+
+	trv, err := tr.cset.AuthenticationV1().TokenReviews().Create(ctx,
+		&authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: token}},
+		metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !trv.Status.Authenticated {
+		if trv.Status.Error != "" {
+			return false, fmt.Errorf("%w: %s", errNotAuthenticated, trv.Status.Error)
+		}
+		return false, errNotAuthenticated
+	}
+	user := trv.Status.User
+	extra := map[string]authzv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authzv1.ExtraValue(v)
+	}
+	sar, err := tr.cset.AuthorizationV1().SubjectAccessReviews().Create(ctx,
+		&authzv1.SubjectAccessReview{Spec: authzv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authzv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		}}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !sar.Status.Allowed {
+		tr.log.Info("access denied", "user", user.Username,
+			"path", path, "reason", sar.Status.Reason)
+		return false, nil
+	}
+	tr.store(key, now)
+	return true, nil
+}
+
+func (tr *tokenReviewer) cached(key string, now time.Time) bool {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+	expiry, ok := tr.cache[key]
+	if !ok {
+		return false
+	}
+	if now.After(expiry) {
+		delete(tr.cache, key)
+		return false
+	}
+	return true
+}
+
+func (tr *tokenReviewer) store(key string, now time.Time) {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+	for k, expiry := range tr.cache {
+		if now.After(expiry) {
+			delete(tr.cache, k)
+		}
+	}
+	tr.cache[key] = now.Add(tokenReviewCacheTTL)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(auth, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", false
+	}
+	token := strings.TrimSpace(parts[1])
+	return token, token != ""
+}
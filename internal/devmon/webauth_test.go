@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/red-hat-storage/hpessa-exporter/internal/devmon"
+	"github.com/stretchr/testify/assert"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTokenReviewFake(tokens map[string]string, allowed map[string]bool) *fake.Clientset {
+	cset := fake.NewSimpleClientset()
+	cset.PrependReactor("create", "tokenreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			trv := action.(k8stesting.CreateAction).GetObject().(*authnv1.TokenReview)
+			if trv.Spec.Token == "unavailable" {
+				return true, nil, errors.New("connection refused")
+			}
+			user, ok := tokens[trv.Spec.Token]
+			trv.Status.Authenticated = ok
+			trv.Status.User.Username = user
+			return true, trv, nil
+		})
+	cset.PrependReactor("create", "subjectaccessreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			sar := action.(k8stesting.CreateAction).GetObject().(*authzv1.SubjectAccessReview)
+			attrs := sar.Spec.NonResourceAttributes
+			sar.Status.Allowed = attrs != nil && attrs.Verb == "get" &&
+				allowed[sar.Spec.User+" "+attrs.Path]
+			return true, sar, nil
+		})
+	return cset
+}
+
+func TestTokenReviewHandler(t *testing.T) {
+	cset := newTokenReviewFake(map[string]string{
+		"token1": "system:serviceaccount:openshift-monitoring:prometheus-k8s",
+		"token2": "system:serviceaccount:default:default",
+	}, map[string]bool{
+		"system:serviceaccount:openshift-monitoring:prometheus-k8s /metrics": true,
+	})
+	handler := devmon.TokenReviewHandler(logr.Discard(), cset,
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+	testCases := []struct {
+		auth string
+		code int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"Bearer token0", http.StatusUnauthorized},
+		{"Bearer unavailable", http.StatusServiceUnavailable},
+		{"Bearer token2", http.StatusForbidden},
+		{"Bearer token1", http.StatusOK},
+		{"bearer token1", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, tc.auth)
+	}
+
+	// successful review is cached
+	reviews := len(cset.Actions())
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer token1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, reviews, len(cset.Actions()))
+
+	// failed review is not cached
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer unavailable")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, reviews+1, len(cset.Actions()))
+}
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+package devmon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// locateTool returns the first of the known locations which is an executable
+// regular file.
+func locateTool(name string, knowns []string) (string, error) {
+	for _, loc := range knowns {
+		fi, err := os.Stat(loc)
+		if err != nil {
+			continue
+		}
+		mode := fi.Mode()
+		if !mode.IsRegular() {
+			continue
+		}
+		if (mode & 0111) > 0 {
+			return loc, nil
+		}
+	}
+	return "", fmt.Errorf("failed to locate %s", name)
+}
+
+// executeCommand runs command and returns its (trimmed) output; the command's
+// process is killed if ctx is done before it completes.
+func executeCommand(ctx context.Context, command string, arg ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, command, arg...)
+	out, err := cmd.Output()
+	if err != nil {
+		return string(out), err
+	}
+	res := strings.TrimSpace(string(out))
+	return res, nil
+}